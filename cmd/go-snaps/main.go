@@ -0,0 +1,213 @@
+// Command go-snaps reviews pending snapshots, written when running tests with UPDATE_SNAPS=pending.
+//
+//	go-snaps review [-root dir] [-dir name] [-filter regexp]
+//	go-snaps list   [-root dir] [-dir name] [-filter regexp]
+//	go-snaps accept [-root dir] [-dir name] (-all | -filter regexp)
+//	go-snaps reject [-root dir] [-dir name] (-all | -filter regexp)
+//
+// By default the module containing the working directory is searched, -filter is matched
+// against the snapshot path relative to it e.g. -filter TestCheckout. Only snapshot files
+// inside snapshot dirs are pending snapshots, -dir is the name set with `snaps.Dir`.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/snaps"
+	"github.com/KoNekoD/go-snaps/snaps/colors"
+	"github.com/KoNekoD/go-snaps/snaps/symbols"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const usage = `usage: go-snaps <command> [flags]
+
+commands:
+  review   interactively accept, reject or skip each pending snapshot
+  list     print pending snapshots
+  accept   accept pending snapshots, requires -all or -filter
+  reject   reject pending snapshots, requires -all or -filter
+`
+
+var errNoSelection = errors.New("either -all or -filter is required")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = io.WriteString(stderr, usage)
+		return 2
+	}
+
+	command := args[0]
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	root := fs.String("root", "", "directory to search for pending snapshots (default: module root)")
+	snapsDir := fs.String("dir", "__snapshots__", "name of the snapshot dirs")
+	filter := fs.String("filter", "", "only pending snapshots whose path matches the regexp")
+	all := fs.Bool("all", false, "select every pending snapshot")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	pending, dir, err := findPending(*root, *snapsDir, *filter)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	switch command {
+	case "review":
+		err = review(pending, dir, stdin, stdout)
+	case "list":
+		for _, p := range pending {
+			_, _ = fmt.Fprintln(stdout, relPath(dir, p.PendingPath))
+		}
+	case "accept", "reject":
+		if !*all && *filter == "" {
+			err = errNoSelection
+			break
+		}
+		err = resolveAll(pending, dir, command == "accept", stdout)
+	default:
+		_, _ = io.WriteString(stderr, usage)
+		return 2
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+func findPending(root, snapsDir, filter string) ([]snaps.PendingSnapshot, string, error) {
+	var (
+		re  *regexp.Regexp
+		err error
+	)
+	if filter != "" {
+		if re, err = regexp.Compile(filter); err != nil {
+			return nil, "", err
+		}
+	}
+	if root == "" {
+		if root, err = moduleRoot(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	pending, err := snaps.FindPendingSnapshots(root, snapsDir, re)
+	return pending, root, err
+}
+
+// moduleRoot returns the closest directory containing a go.mod, falling back to the working directory
+func moduleRoot() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for dir := wd; ; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		if filepath.Dir(dir) == dir {
+			return wd, nil
+		}
+	}
+}
+
+func review(pending []snaps.PendingSnapshot, dir string, stdin io.Reader, stdout io.Writer) error {
+	if len(pending) == 0 {
+		_, _ = fmt.Fprintln(stdout, "no pending snapshots")
+		return nil
+	}
+
+	var accepted, rejected, skipped int
+	scanner := bufio.NewScanner(stdin)
+
+loop:
+	for i, p := range pending {
+		name := relPath(dir, p.Path)
+		title := fmt.Sprintf("\n[%d/%d] %s", i+1, len(pending), name)
+		if p.IsNew() {
+			title += " (new)"
+		}
+		colors.Fprint(stdout, colors.BoldWhite, title+"\n")
+
+		diff, err := p.Diff(name)
+		if err != nil {
+			return err
+		}
+		if diff == "" {
+			diff = "\nno changes\n"
+		}
+		_, _ = io.WriteString(stdout, diff)
+
+		for {
+			_, _ = io.WriteString(stdout, "\naccept (a), reject (r), skip (s), quit (q): ")
+			if !scanner.Scan() {
+				skipped += len(pending) - i
+				break loop
+			}
+
+			switch strings.ToLower(strings.TrimSpace(scanner.Text())) {
+			case "a", "accept":
+				if err := p.Accept(); err != nil {
+					return err
+				}
+				accepted++
+			case "r", "reject":
+				if err := p.Reject(); err != nil {
+					return err
+				}
+				rejected++
+			case "s", "skip":
+				skipped++
+			case "q", "quit":
+				skipped += len(pending) - i
+				break loop
+			default:
+				continue
+			}
+
+			break
+		}
+	}
+
+	_, _ = fmt.Fprintf(stdout, "\n%d accepted, %d rejected, %d skipped\n", accepted, rejected, skipped)
+
+	return nil
+}
+
+func resolveAll(pending []snaps.PendingSnapshot, dir string, accept bool, stdout io.Writer) error {
+	for _, p := range pending {
+		resolve, verb := p.Reject, "rejected"
+		if accept {
+			resolve, verb = p.Accept, "accepted"
+		}
+		if err := resolve(); err != nil {
+			return err
+		}
+
+		colors.Fprint(stdout, colors.Green, fmt.Sprintf("%s%s %s\n", symbols.SuccessSymbol, relPath(dir, p.Path), verb))
+	}
+
+	return nil
+}
+
+func relPath(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return rel
+	}
+
+	return path
+}
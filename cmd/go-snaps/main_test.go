@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"github.com/KoNekoD/go-snaps/snaps/colors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setup(t *testing.T) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "__snapshots__")
+	_ = os.MkdirAll(dir, os.ModePerm)
+	_ = os.WriteFile(filepath.Join(dir, "TestA_1.snap"), []byte("old value"), os.ModePerm)
+	_ = os.WriteFile(filepath.Join(dir, "TestA_1.snap.new"), []byte("new value"), os.ModePerm)
+	_ = os.WriteFile(filepath.Join(dir, "TestB_1.snap.new"), []byte("value"), os.ModePerm)
+
+	return filepath.Dir(dir)
+}
+
+func TestRun(t *testing.T) {
+	t.Cleanup(func() {
+		colors.NOCOLOR = false
+	})
+	colors.NOCOLOR = true
+
+	t.Run("should print usage", func(t *testing.T) {
+		var stderr bytes.Buffer
+
+		test.Equal(t, 2, run(nil, nil, &bytes.Buffer{}, &stderr))
+		test.Contains(t, stderr.String(), "usage: go-snaps")
+	})
+
+	t.Run("should list pending snapshots", func(t *testing.T) {
+		root := setup(t)
+		var stdout bytes.Buffer
+
+		test.Equal(t, 0, run([]string{"list", "-root", root}, nil, &stdout, &bytes.Buffer{}))
+		test.Equal(t, filepath.Join("__snapshots__", "TestA_1.snap.new")+"\n"+
+			filepath.Join("__snapshots__", "TestB_1.snap.new")+"\n", stdout.String())
+	})
+
+	t.Run("should require a selection for accept and reject", func(t *testing.T) {
+		root := setup(t)
+		var stderr bytes.Buffer
+
+		test.Equal(t, 1, run([]string{"accept", "-root", root}, nil, &bytes.Buffer{}, &stderr))
+		test.Contains(t, stderr.String(), errNoSelection.Error())
+	})
+
+	t.Run("should accept filtered snapshots", func(t *testing.T) {
+		root := setup(t)
+
+		test.Equal(t, 0, run([]string{"accept", "-root", root, "-filter", "TestA"}, nil, &bytes.Buffer{}, &bytes.Buffer{}))
+		test.Equal(t, "new value", test.GetFileContent(t, filepath.Join(root, "__snapshots__", "TestA_1.snap")))
+		_, err := os.Stat(filepath.Join(root, "__snapshots__", "TestB_1.snap.new"))
+		test.NoError(t, err)
+	})
+
+	t.Run("should reject all snapshots", func(t *testing.T) {
+		root := setup(t)
+
+		test.Equal(t, 0, run([]string{"reject", "-root", root, "-all"}, nil, &bytes.Buffer{}, &bytes.Buffer{}))
+		test.Equal(t, "old value", test.GetFileContent(t, filepath.Join(root, "__snapshots__", "TestA_1.snap")))
+		_, err := os.Stat(filepath.Join(root, "__snapshots__", "TestB_1.snap.new"))
+		test.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should review interactively", func(t *testing.T) {
+		root := setup(t)
+		var stdout bytes.Buffer
+
+		code := run([]string{"review", "-root", root}, strings.NewReader("x\nr\na\n"), &stdout, &bytes.Buffer{})
+
+		test.Equal(t, 0, code)
+		test.Contains(t, stdout.String(), "[1/2] "+filepath.Join("__snapshots__", "TestA_1.snap"))
+		test.Contains(t, stdout.String(), "+ new value")
+		test.Contains(t, stdout.String(), "[2/2] "+filepath.Join("__snapshots__", "TestB_1.snap")+" (new)")
+		test.Contains(t, stdout.String(), "1 accepted, 1 rejected, 0 skipped")
+		test.Equal(t, "old value", test.GetFileContent(t, filepath.Join(root, "__snapshots__", "TestA_1.snap")))
+		test.Equal(t, "value", test.GetFileContent(t, filepath.Join(root, "__snapshots__", "TestB_1.snap")))
+	})
+
+	t.Run("should skip remaining snapshots on quit", func(t *testing.T) {
+		root := setup(t)
+		var stdout bytes.Buffer
+
+		test.Equal(t, 0, run([]string{"review", "-root", root}, strings.NewReader("s\nq\n"), &stdout, &bytes.Buffer{}))
+		test.Contains(t, stdout.String(), "0 accepted, 0 rejected, 2 skipped")
+	})
+}
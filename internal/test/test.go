@@ -34,6 +34,22 @@ func NewMockTestingT(t *testing.T) MockTestingT {
 	}
 }
 
+// NewRecordingMockTestingT returns a MockTestingT named name, ignoring logs and recording the errors
+// reported to it
+func NewRecordingMockTestingT(t *testing.T, name string) (MockTestingT, *[]any) {
+	mockT := NewMockTestingT(t)
+	mockT.MockName = func() string {
+		return name
+	}
+	mockT.MockLog = func(...any) {}
+	errs := make([]any, 0)
+	mockT.MockError = func(args ...any) {
+		errs = append(errs, args...)
+	}
+
+	return mockT, &errs
+}
+
 func (m MockTestingT) Error(args ...any) {
 	m.t.Helper()
 
@@ -0,0 +1,480 @@
+// Package xmltree parses xml documents into a tree that can be compared, queried with XPath-like
+// paths and written back in a canonical form.
+package xmltree
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+var (
+	ErrNoRoot        = errors.New("missing root element")
+	ErrMultipleRoots = errors.New("multiple root elements")
+	ErrInvalidPath   = errors.New("invalid path")
+)
+
+// Node is either an element or, when Name is empty, a text node
+type Node struct {
+	Name     xml.Name // Space is the namespace url, not the prefix
+	Attrs    []xml.Attr
+	Children []*Node
+	Text     string
+}
+
+// Document is a parsed xml document, comments, processing instructions and text made only of
+// whitespace e.g. indentation are dropped
+type Document struct {
+	Root     *Node
+	prefixes map[string]string // namespace url -> first prefix declared for it
+}
+
+// Parse parses a document, names are resolved to namespace urls
+func Parse(b []byte) (*Document, error) {
+	doc := &Document{prefixes: make(map[string]string)}
+	decoder := xml.NewDecoder(bytes.NewReader(b))
+
+	var stack []*Node
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &Node{Name: t.Name}
+			for _, attr := range t.Attr {
+				switch {
+				case attr.Name.Space == "xmlns":
+					doc.declare(attr.Value, attr.Name.Local)
+				case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+					doc.declare(attr.Value, "")
+				default:
+					n.Attrs = append(n.Attrs, attr)
+				}
+			}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+			} else if doc.Root != nil {
+				return nil, ErrMultipleRoots
+			} else {
+				doc.Root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			text := string(t)
+			if strings.TrimSpace(text) == "" || len(stack) == 0 {
+				continue
+			}
+			parent := stack[len(stack)-1]
+			if last := len(parent.Children) - 1; last >= 0 && parent.Children[last].Name.Local == "" {
+				parent.Children[last].Text += text // text split by a comment
+				continue
+			}
+			parent.Children = append(parent.Children, &Node{Text: text})
+		}
+	}
+
+	if doc.Root == nil {
+		return nil, ErrNoRoot
+	}
+
+	return doc, nil
+}
+
+func (d *Document) declare(url, prefix string) {
+	if _, ok := d.prefixes[url]; !ok {
+		d.prefixes[url] = prefix
+	}
+}
+
+// Equal reports whether both documents have the same elements, attributes and text, regardless of
+// namespace prefixes and attribute order
+func Equal(a, b *Document) bool {
+	return equalNodes(a.Root, b.Root)
+}
+
+func equalNodes(a, b *Node) bool {
+	if a.Name != b.Name || a.Text != b.Text || len(a.Attrs) != len(b.Attrs) || len(a.Children) != len(b.Children) {
+		return false
+	}
+
+	attrsA, attrsB := sortedAttrs(a.Attrs), sortedAttrs(b.Attrs)
+	for i := range attrsA {
+		if attrsA[i] != attrsB[i] {
+			return false
+		}
+	}
+	for i := range a.Children {
+		if !equalNodes(a.Children[i], b.Children[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortedAttrs(attrs []xml.Attr) []xml.Attr {
+	sorted := append([]xml.Attr(nil), attrs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Name.Space != sorted[j].Name.Space {
+			return sorted[i].Name.Space < sorted[j].Name.Space
+		}
+		return sorted[i].Name.Local < sorted[j].Name.Local
+	})
+
+	return sorted
+}
+
+// Encode writes the document indented by 2 spaces, with every namespace declared on the root element,
+// attributes sorted, empty elements self-closed and elements containing text written inline, as is
+func (d *Document) Encode() string {
+	prefixes := d.assignPrefixes()
+
+	var b strings.Builder
+	d.encodeNode(&b, d.Root, prefixes, 0)
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// assignPrefixes returns the prefix of every namespace url in use, preferring the first prefix
+// declared for it. Attributes can't use the default namespace.
+func (d *Document) assignPrefixes() map[string]string {
+	var (
+		urls         []string
+		seen         = make(map[string]bool)
+		inAttrs      = make(map[string]bool)
+		unqualified  bool
+		collectNames func(n *Node)
+	)
+	collectNames = func(n *Node) {
+		if n.Name.Local == "" {
+			return
+		}
+		names := []xml.Name{n.Name}
+		for _, attr := range n.Attrs {
+			names = append(names, attr.Name)
+			inAttrs[attr.Name.Space] = true
+		}
+		unqualified = unqualified || n.Name.Space == ""
+		for _, name := range names {
+			if name.Space != "" && name.Space != xmlNamespace && !seen[name.Space] {
+				seen[name.Space] = true
+				urls = append(urls, name.Space)
+			}
+		}
+		for _, child := range n.Children {
+			collectNames(child)
+		}
+	}
+	collectNames(d.Root)
+
+	prefixes := map[string]string{xmlNamespace: "xml"}
+	taken := map[string]bool{"xml": true, "xmlns": true}
+	for _, url := range urls {
+		prefix, ok := d.prefixes[url]
+		if ok && !taken[prefix] && (prefix != "" || (!inAttrs[url] && !unqualified)) {
+			prefixes[url], taken[prefix] = prefix, true
+		}
+	}
+	n := 0
+	for _, url := range urls {
+		if _, ok := prefixes[url]; ok {
+			continue
+		}
+		for {
+			n++
+			if prefix := "ns" + strconv.Itoa(n); !taken[prefix] {
+				prefixes[url], taken[prefix] = prefix, true
+				break
+			}
+		}
+	}
+
+	return prefixes
+}
+
+func (d *Document) encodeNode(b *strings.Builder, n *Node, prefixes map[string]string, depth int) {
+	indent := strings.Repeat("  ", depth)
+	if n.Name.Local == "" {
+		b.WriteString(indent + escapeText(n.Text) + "\n")
+		return
+	}
+
+	b.WriteString(indent)
+	switch {
+	case len(n.Children) == 0 || hasText(n):
+		// whitespace is significant in mixed content, it's written as is
+		d.encodeInline(b, n, prefixes, depth == 0)
+		b.WriteString("\n")
+	default:
+		b.WriteString(d.startTag(n, prefixes, depth == 0) + ">\n")
+		for _, child := range n.Children {
+			d.encodeNode(b, child, prefixes, depth+1)
+		}
+		b.WriteString(indent + "</" + qualifiedName(n.Name, prefixes) + ">\n")
+	}
+}
+
+// encodeInline writes the node and its children without indentation
+func (d *Document) encodeInline(b *strings.Builder, n *Node, prefixes map[string]string, root bool) {
+	if n.Name.Local == "" {
+		b.WriteString(escapeText(n.Text))
+		return
+	}
+	if len(n.Children) == 0 {
+		b.WriteString(d.startTag(n, prefixes, root) + "/>")
+		return
+	}
+
+	b.WriteString(d.startTag(n, prefixes, root) + ">")
+	for _, child := range n.Children {
+		d.encodeInline(b, child, prefixes, false)
+	}
+	b.WriteString("</" + qualifiedName(n.Name, prefixes) + ">")
+}
+
+// startTag returns the start tag of the element without its closing `>`, the root element declares
+// every namespace
+func (d *Document) startTag(n *Node, prefixes map[string]string, root bool) string {
+	attrs := make([]string, 0, len(n.Attrs)+len(prefixes))
+	if root {
+		for url, prefix := range prefixes {
+			switch {
+			case url == xmlNamespace:
+			case prefix == "":
+				attrs = append(attrs, fmt.Sprintf(`xmlns="%s"`, escapeAttr(url)))
+			default:
+				attrs = append(attrs, fmt.Sprintf(`xmlns:%s="%s"`, prefix, escapeAttr(url)))
+			}
+		}
+		sort.Strings(attrs)
+	}
+	declarations := len(attrs)
+	for _, attr := range n.Attrs {
+		attrName := attr.Name.Local
+		if attr.Name.Space != "" {
+			attrName = qualifiedName(attr.Name, prefixes)
+		}
+		attrs = append(attrs, fmt.Sprintf(`%s="%s"`, attrName, escapeAttr(attr.Value)))
+	}
+	sort.Strings(attrs[declarations:])
+
+	tag := "<" + qualifiedName(n.Name, prefixes)
+	for _, attr := range attrs {
+		tag += " " + attr
+	}
+
+	return tag
+}
+
+// hasText reports whether the element contains text, alone or mixed with elements
+func hasText(n *Node) bool {
+	for _, child := range n.Children {
+		if child.Name.Local == "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func qualifiedName(name xml.Name, prefixes map[string]string) string {
+	if prefix := prefixes[name.Space]; name.Space != "" && prefix != "" {
+		return prefix + ":" + name.Local
+	}
+
+	return name.Local
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	attrEscaper = strings.NewReplacer(
+		"&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "\n", "&#xA;", "\r", "&#xD;", "\t", "&#x9;",
+	)
+)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+
+func escapeAttr(s string) string { return attrEscaper.Replace(s) }
+
+// Match is a value selected by a path, the text of an element or the value of an attribute
+type Match struct {
+	node *Node
+	attr int // index of the attribute, -1 for elements
+}
+
+// Value returns the text of the element or the value of the attribute
+func (m Match) Value() string {
+	if m.attr >= 0 {
+		return m.node.Attrs[m.attr].Value
+	}
+
+	var b strings.Builder
+	var collectText func(n *Node)
+	collectText = func(n *Node) {
+		b.WriteString(n.Text)
+		for _, child := range n.Children {
+			collectText(child)
+		}
+	}
+	collectText(m.node)
+
+	return b.String()
+}
+
+// Set replaces the value of the attribute or the content of the element with text
+func (m Match) Set(value string) {
+	if m.attr >= 0 {
+		m.node.Attrs[m.attr].Value = value
+		return
+	}
+
+	m.node.Children = []*Node{{Text: value}}
+}
+
+type step struct {
+	descendant bool
+	attr       bool
+	name       string // local name or `*`
+	index      int    // 1-based position among matching siblings, 0 for all
+}
+
+// Select returns the elements and attributes at path, a subset of XPath
+//
+//	/Envelope/Body/Item    elements by local name, namespace prefixes are ignored
+//	//Item                 elements at any depth
+//	/Envelope/*/Item[2]    any element, the second matching child
+//	//Assertion/@ID        attributes
+func (d *Document) Select(path string) ([]Match, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	context := []*Node{{Children: []*Node{d.Root}}} // the document node
+	for i, s := range steps {
+		bases := context
+		if s.descendant {
+			bases = descendants(context)
+		}
+
+		if s.attr {
+			if i != len(steps)-1 {
+				return nil, fmt.Errorf("%w %q: attributes must be the last step", ErrInvalidPath, path)
+			}
+			matches := make([]Match, 0)
+			for _, n := range bases {
+				for j, attr := range n.Attrs {
+					if s.name == "*" || attr.Name.Local == s.name {
+						matches = append(matches, Match{node: n, attr: j})
+					}
+				}
+			}
+			return matches, nil
+		}
+
+		next := make([]*Node, 0)
+		for _, n := range bases {
+			position := 0
+			for _, child := range n.Children {
+				if child.Name.Local == "" || (s.name != "*" && child.Name.Local != s.name) {
+					continue
+				}
+				position++
+				if s.index == 0 || s.index == position {
+					next = append(next, child)
+				}
+			}
+		}
+		context = next
+	}
+
+	matches := make([]Match, len(context))
+	for i, n := range context {
+		matches[i] = Match{node: n, attr: -1}
+	}
+
+	return matches, nil
+}
+
+// descendants returns the nodes and their descendant elements, without duplicates
+func descendants(nodes []*Node) []*Node {
+	var (
+		result []*Node
+		seen   = make(map[*Node]bool)
+		walk   func(n *Node)
+	)
+	walk = func(n *Node) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		result = append(result, n)
+		for _, child := range n.Children {
+			if child.Name.Local != "" {
+				walk(child)
+			}
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+
+	return result
+}
+
+func parsePath(path string) ([]step, error) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	steps := make([]step, 0, len(segments))
+
+	descendant := false
+	for i, segment := range segments {
+		if segment == "" {
+			if descendant || i == len(segments)-1 {
+				return nil, fmt.Errorf("%w %q", ErrInvalidPath, path)
+			}
+			descendant = true
+			continue
+		}
+
+		s := step{descendant: descendant}
+		descendant = false
+		if name, ok := strings.CutPrefix(segment, "@"); ok {
+			s.attr, segment = true, name
+		}
+		if name, index, ok := strings.Cut(segment, "["); ok {
+			n, err := strconv.Atoi(strings.TrimSuffix(index, "]"))
+			if err != nil || n < 1 || !strings.HasSuffix(index, "]") || s.attr {
+				return nil, fmt.Errorf("%w %q", ErrInvalidPath, path)
+			}
+			s.index, segment = n, name
+		}
+		if _, local, ok := strings.Cut(segment, ":"); ok {
+			segment = local
+		}
+		if segment == "" {
+			return nil, fmt.Errorf("%w %q", ErrInvalidPath, path)
+		}
+		s.name = segment
+
+		steps = append(steps, s)
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,161 @@
+package xmltree
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"testing"
+)
+
+const envelope = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- request -->
+<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">
+  <soap:Header>
+    <wsse:Timestamp xmlns:wsse="urn:wsse" wsse:Id="TS-1"><wsse:Created>2024-01-01T00:00:00Z</wsse:Created></wsse:Timestamp>
+  </soap:Header>
+  <soap:Body>
+    <m:GetPrice xmlns:m="urn:prices" b="2" a="1">
+      <m:Item><![CDATA[Apples & Pears]]></m:Item>
+      <m:Note></m:Note>
+    </m:GetPrice>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestParse(t *testing.T) {
+	t.Run("should report invalid documents", func(t *testing.T) {
+		_, err := Parse([]byte(`<a><b></a>`))
+		test.True(t, err != nil)
+
+		_, err = Parse([]byte(`<!-- nothing -->`))
+		test.True(t, errors.Is(err, ErrNoRoot))
+
+		_, err = Parse([]byte(`<a/><b/>`))
+		test.True(t, errors.Is(err, ErrMultipleRoots))
+	})
+}
+
+func TestEncode(t *testing.T) {
+	t.Run("should write a canonical form", func(t *testing.T) {
+		doc, err := Parse([]byte(envelope))
+
+		test.NoError(t, err)
+		test.Equal(t, `<soap:Envelope xmlns:m="urn:prices" xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:wsse="urn:wsse">
+  <soap:Header>
+    <wsse:Timestamp wsse:Id="TS-1">
+      <wsse:Created>2024-01-01T00:00:00Z</wsse:Created>
+    </wsse:Timestamp>
+  </soap:Header>
+  <soap:Body>
+    <m:GetPrice a="1" b="2">
+      <m:Item>Apples &amp; Pears</m:Item>
+      <m:Note/>
+    </m:GetPrice>
+  </soap:Body>
+</soap:Envelope>`, doc.Encode())
+	})
+
+	t.Run("should keep whitespace of mixed content", func(t *testing.T) {
+		doc, err := Parse([]byte(`<doc>
+  <p>a <b>x</b> c<!-- note --> d</p>
+  <q>
+    <r/>
+  </q>
+</doc>`))
+
+		test.NoError(t, err)
+		encoded := doc.Encode()
+		test.Equal(t, `<doc>
+  <p>a <b>x</b> c d</p>
+  <q>
+    <r/>
+  </q>
+</doc>`, encoded)
+
+		reparsed, err := Parse([]byte(encoded))
+		test.NoError(t, err)
+		test.True(t, Equal(doc, reparsed))
+	})
+
+	t.Run("should not use the default namespace for attributes and unqualified elements", func(t *testing.T) {
+		doc, err := Parse([]byte(`<a xmlns="urn:a"><b xmlns="" c="1"/><x:d xmlns:x="urn:a" x:e="2"/></a>`))
+
+		test.NoError(t, err)
+		test.Equal(t, `<ns1:a xmlns:ns1="urn:a">
+  <b c="1"/>
+  <ns1:d ns1:e="2"/>
+</ns1:a>`, doc.Encode())
+	})
+}
+
+func TestEqual(t *testing.T) {
+	parse := func(s string) *Document {
+		doc, err := Parse([]byte(s))
+		test.NoError(t, err)
+		return doc
+	}
+
+	t.Run("should ignore prefixes, attribute order and whitespace", func(t *testing.T) {
+		test.True(t, Equal(
+			parse(`<p:a xmlns:p="urn:a" y="2" x="1">  <p:b>text</p:b></p:a>`),
+			parse(`<a xmlns="urn:a" x="1" y="2"><b>text</b></a>`),
+		))
+	})
+
+	t.Run("should compare text whitespace", func(t *testing.T) {
+		test.False(t, Equal(parse(`<p>a <b>x</b> c</p>`), parse(`<p>a<b>x</b>c</p>`)))
+	})
+
+	t.Run("should compare namespaces, values and children", func(t *testing.T) {
+		test.False(t, Equal(parse(`<a xmlns="urn:a"/>`), parse(`<a xmlns="urn:b"/>`)))
+		test.False(t, Equal(parse(`<a x="1"/>`), parse(`<a x="2"/>`)))
+		test.False(t, Equal(parse(`<a><b/><c/></a>`), parse(`<a><c/><b/></a>`)))
+	})
+}
+
+func TestSelect(t *testing.T) {
+	doc, err := Parse([]byte(`<r><a id="1"><b>x</b></a><a id="2"><b>y</b><b>z</b></a></r>`))
+	test.NoError(t, err)
+
+	values := func(t *testing.T, path string) []string {
+		t.Helper()
+		matches, err := doc.Select(path)
+		test.NoError(t, err)
+
+		values := make([]string, len(matches))
+		for i, m := range matches {
+			values[i] = m.Value()
+		}
+		return values
+	}
+
+	t.Run("should select elements and attributes", func(t *testing.T) {
+		test.Equal(t, []string{"x", "yz"}, values(t, "/r/a"))
+		test.Equal(t, []string{"x", "y", "z"}, values(t, "//b"))
+		test.Equal(t, []string{"x", "y"}, values(t, "/r/*/b[1]"))
+		test.Equal(t, []string{"1", "2"}, values(t, "//a/@id"))
+		test.Equal(t, []string{"2"}, values(t, "r/a[2]/@*"))
+		test.Equal(t, []string{}, values(t, "/a"))
+	})
+
+	t.Run("should report invalid paths", func(t *testing.T) {
+		for _, path := range []string{"", "/r/", "///r", "/r/@id/a", "/r/a[0]", "/r/a[x]", "//@id[1]"} {
+			_, err := doc.Select(path)
+			test.True(t, errors.Is(err, ErrInvalidPath))
+		}
+	})
+
+	t.Run("should replace values", func(t *testing.T) {
+		matches, err := doc.Select("//a[2]")
+		test.NoError(t, err)
+		matches[0].Set("<Any value>")
+		matches, err = doc.Select("//@id")
+		test.NoError(t, err)
+		matches[0].Set(`"`)
+
+		test.Equal(t, `<r>
+  <a id="&quot;">
+    <b>x</b>
+  </a>
+  <a id="2">&lt;Any value&gt;</a>
+</r>`, doc.Encode())
+	})
+}
@@ -0,0 +1,55 @@
+package snaps
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// bazelRunfiles returns the runfiles dir of the main workspace when running under Bazel e.g.
+// `bazel test` or `bazel run`, where sources are read-only symlinks
+func bazelRunfiles() (string, bool) {
+	srcdir := os.Getenv("TEST_SRCDIR")
+	if srcdir == "" {
+		srcdir = os.Getenv("RUNFILES_DIR")
+	}
+	if srcdir == "" {
+		return "", false
+	}
+
+	workspace := os.Getenv("TEST_WORKSPACE")
+	if workspace == "" {
+		workspace = "_main"
+	}
+
+	return filepath.Join(srcdir, workspace), true
+}
+
+// bazelOutputDir returns where snapshots are written when running under Bazel, the workspace
+// for `bazel run` and the undeclared outputs dir for `bazel test`
+//
+//	bazel run //pkg:pkg_test -- -test.run TestFoo
+//	bazel test //pkg:pkg_test --test_env=UPDATE_SNAPS=true  # bazel-testlogs/pkg/pkg_test/test.outputs
+func bazelOutputDir() (string, bool) {
+	if dir := os.Getenv("BUILD_WORKSPACE_DIRECTORY"); dir != "" {
+		return dir, true
+	}
+	if dir := os.Getenv("TEST_UNDECLARED_OUTPUTS_DIR"); dir != "" {
+		return dir, true
+	}
+
+	return "", false
+}
+
+// bazelStore reads snapshots from runfiles and writes them mirrored to the Bazel output dir
+func bazelStore(base Store) (Store, bool) {
+	runfiles, ok := bazelRunfiles()
+	if !ok {
+		return nil, false
+	}
+	out, ok := bazelOutputDir()
+	if !ok {
+		return nil, false
+	}
+
+	return newMirrorStore(base, runfiles, out), true
+}
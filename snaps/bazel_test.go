@@ -0,0 +1,56 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBazel(t *testing.T) {
+	resetEnv(t)
+
+	srcdir, out := t.TempDir(), t.TempDir()
+	snapsDir := filepath.Join(srcdir, "_main", "pkg", "__snapshots__")
+	_ = os.MkdirAll(snapsDir, os.ModePerm)
+	_ = os.WriteFile(filepath.Join(srcdir, "_main", "pkg", "foo_test.go"), []byte("package pkg"), os.ModePerm)
+	_ = os.WriteFile(filepath.Join(snapsDir, "TestBazel_1.snap"), []byte("accepted"), os.ModePerm)
+	t.Setenv("TEST_SRCDIR", srcdir)
+	t.Setenv("TEST_WORKSPACE", "_main")
+	t.Setenv("TEST_UNDECLARED_OUTPUTS_DIR", out)
+	t.Setenv("BUILD_WORKSPACE_DIRECTORY", "")
+
+	t.Run("should resolve workspace relative test files from runfiles", func(t *testing.T) {
+		dir, err := lookupCallerDir("pkg/foo_test.go")
+
+		test.NoError(t, err)
+		test.Equal(t, filepath.Join(srcdir, "_main", "pkg"), dir)
+	})
+
+	t.Run("should read from runfiles and write to undeclared outputs", func(t *testing.T) {
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestBazel")
+		mockT.MockCleanup = t.Cleanup
+		c := WithConfig(Dir(snapsDir))
+
+		c.MatchSnapshot(mockT, "accepted")
+		c.MatchSnapshot(mockT, "added")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "added", test.GetFileContent(t, filepath.Join(out, "pkg", "__snapshots__", "TestBazel_2.snap")))
+		_, err := os.Stat(filepath.Join(snapsDir, "TestBazel_2.snap"))
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("should write to the workspace with bazel run", func(t *testing.T) {
+		workspace := t.TempDir()
+		t.Setenv("BUILD_WORKSPACE_DIRECTORY", workspace)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestBazelRun")
+
+		WithConfig(Dir(snapsDir)).MatchSnapshot(mockT, "added")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "added", test.GetFileContent(t, filepath.Join(workspace, "pkg", "__snapshots__", "TestBazelRun_1.snap")))
+	})
+}
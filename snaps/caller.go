@@ -0,0 +1,133 @@
+package snaps
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	errCallerDirNotFound = errors.New("could not locate the directory of the test file")
+	callerDirs           sync.Map // caller file -> resolved dir
+)
+
+// resolveCallerDir returns the directory of the test file reported by runtime.Caller.
+//
+// Test binaries built with -trimpath report module relative paths e.g. `github.com/org/repo/pkg/foo_test.go`,
+// those are resolved from the root of the module containing the working directory. Binaries built elsewhere
+// report absolute paths that don't exist, those are resolved by finding the same package in the module.
+// Under Bazel paths are relative to the workspace and resolved from runfiles. As a last resort the
+// working directory is used if it contains the test file, `go test` runs test binaries in the package dir.
+func resolveCallerDir(file string) (string, error) {
+	if dir, ok := callerDirs.Load(file); ok {
+		return dir.(string), nil
+	}
+
+	dir, err := lookupCallerDir(file)
+	if err != nil {
+		return "", err
+	}
+	callerDirs.Store(file, dir)
+
+	return dir, nil
+}
+
+func lookupCallerDir(file string) (string, error) {
+	if filepath.IsAbs(file) && fileExists(file) {
+		return filepath.Dir(file), nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	if root, module, ok := findModule(wd); ok {
+		slashed := filepath.ToSlash(file)
+		if rel, ok := strings.CutPrefix(slashed, module+"/"); ok && !filepath.IsAbs(file) {
+			if dir := filepath.Join(root, filepath.FromSlash(rel)); fileExists(dir) {
+				return filepath.Dir(dir), nil
+			}
+		}
+
+		// try every suffix of the path keeping at least a dir e.g. `/build/src/pkg/foo_test.go` -> `<root>/src/pkg`,
+		// `<root>/pkg`, the bare file name would match unrelated files in the module root
+		parts := strings.Split(strings.TrimPrefix(slashed, "/"), "/")
+		for i := 0; i < len(parts)-1; i++ {
+			if candidate := filepath.Join(root, filepath.Join(parts[i:]...)); fileExists(candidate) {
+				return filepath.Dir(candidate), nil
+			}
+		}
+	}
+
+	// rules_go reports paths relative to the workspace
+	if runfiles, ok := bazelRunfiles(); ok && !filepath.IsAbs(file) {
+		if candidate := filepath.Join(runfiles, file); fileExists(candidate) {
+			return filepath.Dir(candidate), nil
+		}
+	}
+
+	if fileExists(filepath.Join(wd, filepath.Base(file))) {
+		return wd, nil
+	}
+
+	return "", fmt.Errorf("%w %s, run tests from the package directory or use an absolute snaps.Dir", errCallerDirNotFound, file)
+}
+
+// sourceRoot returns the root of the module containing the working directory or the working directory itself
+var sourceRoot = sync.OnceValue(func() string {
+	wd, _ := os.Getwd()
+	if root, _, ok := findModule(wd); ok {
+		return root
+	}
+
+	return wd
+})
+
+// findModule returns the closest directory containing a go.mod and its module path
+func findModule(dir string) (string, string, bool) {
+	for ; ; dir = filepath.Dir(dir) {
+		if module, ok := modulePathOf(filepath.Join(dir, "go.mod")); ok {
+			return dir, module, true
+		}
+		if filepath.Dir(dir) == dir {
+			return "", "", false
+		}
+	}
+}
+
+func modulePathOf(gomod string) (string, bool) {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`), true
+		}
+	}
+
+	return "", false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// callerFile returns the path of the test file reported by runtime.Caller on this machine
+func callerFile(file string) (string, error) {
+	dir, err := resolveCallerDir(file)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, filepath.Base(file)), nil
+}
@@ -0,0 +1,58 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLookupCallerDir(t *testing.T) {
+	wd, _ := os.Getwd()
+
+	t.Run("should keep existing absolute paths", func(t *testing.T) {
+		dir, err := lookupCallerDir(filepath.Join(wd, "caller_test.go"))
+
+		test.NoError(t, err)
+		test.Equal(t, wd, dir)
+	})
+
+	t.Run("should resolve module relative paths of -trimpath builds", func(t *testing.T) {
+		dir, err := lookupCallerDir("github.com/KoNekoD/go-snaps/snaps/caller_test.go")
+
+		test.NoError(t, err)
+		test.Equal(t, wd, dir)
+	})
+
+	t.Run("should resolve absolute paths of binaries built elsewhere", func(t *testing.T) {
+		dir, err := lookupCallerDir("/build/go-snaps/snaps/caller_test.go")
+
+		test.NoError(t, err)
+		test.Equal(t, wd, dir)
+	})
+
+	t.Run("should return error when the test file can't be found", func(t *testing.T) {
+		_, err := lookupCallerDir("example.com/other/missing_test.go")
+
+		test.True(t, errors.Is(err, errCallerDirNotFound))
+	})
+
+	t.Run("should not match the file name alone in the module root", func(t *testing.T) {
+		_, err := lookupCallerDir("/build/other/go.mod")
+
+		test.True(t, errors.Is(err, errCallerDirNotFound))
+	})
+}
+
+func TestFindModule(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "go.mod"), []byte("// comment\nmodule example.com/mod\n\ngo 1.23\n"), os.ModePerm)
+	_ = os.MkdirAll(filepath.Join(dir, "pkg", "sub"), os.ModePerm)
+
+	root, module, ok := findModule(filepath.Join(dir, "pkg", "sub"))
+
+	test.True(t, ok)
+	test.Equal(t, dir, root)
+	test.Equal(t, "example.com/mod", module)
+}
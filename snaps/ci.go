@@ -0,0 +1,109 @@
+package snaps
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/snaps/colors"
+	"strconv"
+)
+
+var (
+	errObsoleteSnapshots = errors.New("obsolete snapshots found on CI")
+	errPendingSnapshots  = errors.New("pending snapshots found on CI")
+)
+
+// CIAction determines what happens on CI when a snapshot check doesn't pass
+type CIAction string
+
+const (
+	// CIFail reports an error, failing the test or making `Clean` return an error
+	CIFail CIAction = "fail"
+	// CIWarn logs a warning and lets the test pass
+	CIWarn CIAction = "warn"
+	// CIIgnore lets the test pass silently, ignored snapshots are only counted in the `Clean` summary
+	CIIgnore CIAction = "ignore"
+	// CIUpdate behaves as outside CI: missing snapshots are written, mismatching ones are
+	// updated and obsolete ones removed. It's the same as CIFail for pending snapshots.
+	CIUpdate CIAction = "update"
+)
+
+// CIPolicy determines what happens on CI for each kind of snapshot problem, unset fields keep the default
+//
+//	Missing:  CIFail
+//	Mismatch: CIFail
+//	Obsolete: CIWarn, reported by `Clean`
+//	Pending:  CIIgnore, `.snap.new` files left in snapshot dirs are reported by `Clean`
+type CIPolicy struct {
+	Missing  CIAction
+	Mismatch CIAction
+	Obsolete CIAction
+	Pending  CIAction
+}
+
+// withDefaults fills unset fields with the default actions
+func (p CIPolicy) withDefaults() CIPolicy {
+	if p.Missing == "" {
+		p.Missing = CIFail
+	}
+	if p.Mismatch == "" {
+		p.Mismatch = CIFail
+	}
+	if p.Obsolete == "" {
+		p.Obsolete = CIWarn
+	}
+	if p.Pending == "" {
+		p.Pending = CIIgnore
+	}
+
+	return p
+}
+
+// mode returns the update mode of snapshots on CI
+func (p CIPolicy) mode() UpdateMode {
+	missing, mismatch := p.Missing == CIUpdate, p.Mismatch == CIUpdate
+	switch {
+	case missing && mismatch:
+		return updateMismatches
+	case missing:
+		return UpdateNew
+	case mismatch:
+		return UpdateFailing
+	}
+
+	return UpdateNone
+}
+
+// IsCI reports whether tests run on CI, in order of precedence from `RunningOnCI`, SNAPS_CI and CI detection
+func (c *Config) IsCI() bool {
+	if c.ci != nil {
+		return *c.ci
+	}
+	if on, err := strconv.ParseBool(ciVAR); err == nil {
+		return on
+	}
+
+	return isCI
+}
+
+// CI returns the policy applied on CI, with defaults for unset fields
+func (c *Config) CI() CIPolicy { return c.ciPolicy.withDefaults() }
+
+// handleFailure reports a missing or mismatching snapshot that wasn't written, on CI following the action of the policy
+func (s *snap) handleFailure(action CIAction, err any) {
+	s.t.Helper()
+
+	if !s.c.IsCI() {
+		s.handleError(err)
+		return
+	}
+
+	switch action {
+	case CIWarn:
+		s.t.Log(colors.Sprint(colors.Yellow, fmt.Sprint("[warning] ", err)))
+		s.registerTestEvent(warned)
+	case CIIgnore:
+		s.registerTestEvent(ignored)
+	default:
+		s.handleError(err)
+	}
+}
@@ -0,0 +1,133 @@
+package snaps
+
+import (
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCIPolicy(t *testing.T) {
+	t.Run("should fill defaults", func(t *testing.T) {
+		test.Equal(
+			t,
+			CIPolicy{Missing: CIUpdate, Mismatch: CIFail, Obsolete: CIWarn, Pending: CIIgnore},
+			WithConfig(CI(CIPolicy{Missing: CIUpdate})).CI(),
+		)
+	})
+
+	t.Run("should map to update modes", func(t *testing.T) {
+		test.Equal(t, UpdateNone, CIPolicy{Missing: CIWarn, Mismatch: CIIgnore}.mode())
+		test.Equal(t, UpdateNew, CIPolicy{Missing: CIUpdate}.mode())
+		test.Equal(t, UpdateFailing, CIPolicy{Mismatch: CIUpdate}.mode())
+		test.Equal(t, updateMismatches, CIPolicy{Missing: CIUpdate, Mismatch: CIUpdate}.mode())
+	})
+
+	t.Run("should override CI detection", func(t *testing.T) {
+		resetEnv(t)
+		isCI = true
+
+		test.True(t, defaultConfig().IsCI())
+		test.False(t, WithConfig(RunningOnCI(false)).IsCI())
+
+		ciVAR = "false"
+		test.False(t, defaultConfig().IsCI())
+		test.True(t, WithConfig(RunningOnCI(true)).IsCI())
+	})
+
+	t.Run("should delete obsolete snapshots on CI only with CIUpdate", func(t *testing.T) {
+		resetEnv(t)
+		isCI = true
+
+		test.False(t, WithConfig(DeleteObsolete()).shouldDeleteObsolete())
+		test.True(t, WithConfig(CI(CIPolicy{Obsolete: CIUpdate})).shouldDeleteObsolete())
+	})
+}
+
+func TestMatchSnapshotOnCI(t *testing.T) {
+	resetEnv(t)
+	isCI = true
+
+	t.Run("should fail missing snapshots by default", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchSnapshotOnCI")
+
+		WithConfig(Dir(dir)).MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, []any{errSnapNotFound}, *errs)
+		_, err := os.Stat(filepath.Join(dir, "TestMatchSnapshotOnCI_1.snap"))
+		test.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should write missing snapshots with CIUpdate", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchSnapshotOnCI")
+
+		WithConfig(Dir(dir), CI(CIPolicy{Missing: CIUpdate})).MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "hello world", test.GetFileContent(t, filepath.Join(dir, "TestMatchSnapshotOnCI_1.snap")))
+	})
+
+	t.Run("should warn on mismatches with CIWarn", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(dir, "TestMatchSnapshotOnCI_1.snap"), []byte("hello world"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchSnapshotOnCI")
+		logs := make([]any, 0)
+		mockT.MockLog = func(args ...any) {
+			logs = append(logs, args...)
+		}
+
+		WithConfig(Dir(dir), CI(CIPolicy{Mismatch: CIWarn})).MatchSnapshot(mockT, "hello universe")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, 1, len(logs))
+		test.Contains(t, logs[0].(string), "[warning]")
+		test.Equal(t, "hello world", test.GetFileContent(t, filepath.Join(dir, "TestMatchSnapshotOnCI_1.snap")))
+	})
+
+	t.Run("should ignore mismatches with CIIgnore", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(dir, "TestMatchSnapshotOnCI_1.snap"), []byte("hello world"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchSnapshotOnCI")
+		logs := make([]any, 0)
+		mockT.MockLog = func(args ...any) {
+			logs = append(logs, args...)
+		}
+		defaultRegistry.testEventsMutex.Lock()
+		before := defaultRegistry.testEvents[ignored]
+		defaultRegistry.testEventsMutex.Unlock()
+
+		WithConfig(Dir(dir), CI(CIPolicy{Mismatch: CIIgnore})).MatchSnapshot(mockT, "hello universe")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, 0, len(logs))
+		defaultRegistry.testEventsMutex.Lock()
+		test.Equal(t, before+1, defaultRegistry.testEvents[ignored])
+		defaultRegistry.testEventsMutex.Unlock()
+	})
+
+	t.Run("should fail outside CI regardless of the policy", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchSnapshotOnCI")
+
+		WithConfig(Dir(dir), RunningOnCI(false), Mode(UpdateNone), CI(CIPolicy{Missing: CIIgnore})).
+			MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, []any{errSnapNotFound}, *errs)
+	})
+}
+
+func TestPendingFiles(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "TestA_1.snap"), []byte("a"), os.ModePerm)
+	_ = os.WriteFile(filepath.Join(dir, "TestA_2.snap.new"), []byte("a"), os.ModePerm)
+	r := newSnapRegistry()
+	r.registryDirs[dir] = map[string]int{".snap": 1}
+	r.registryDirs[filepath.Join(dir, "missing")] = map[string]int{".snap": 1}
+
+	pending, err := r.pendingFiles()
+
+	test.NoError(t, err)
+	test.Equal(t, []string{filepath.Join(dir, "TestA_2.snap.new")}, pending)
+}
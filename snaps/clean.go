@@ -0,0 +1,519 @@
+package snaps
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/snaps/colors"
+	"github.com/KoNekoD/go-snaps/snaps/symbols"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Clean reports snapshot files no test produced during the run and prints a summary
+// of added, updated, passed and failed snapshots.
+//
+// Must be called from TestMain after the tests have run
+//
+//	func TestMain(m *testing.M) {
+//		v := m.Run()
+//
+//		// After all tests have run go-snaps can check for obsolete snapshots
+//		snaps.Clean(m)
+//
+//		os.Exit(v)
+//	}
+//
+// Snapshots of tests tracked with snaps.Skip are never reported as obsolete.
+// Running with UPDATE_SNAPS=clean removes obsolete snapshots, see `DeleteObsolete`.
+// Clean returns whether obsolete snapshots were found, on CI it returns an error for
+// obsolete and pending snapshots the `CI` policy fails.
+func Clean(m *testing.M) (bool, error) {
+	return defaultConfig().Clean(m)
+}
+
+// Clean reports snapshot files no test produced during the run and prints a summary
+// of added, updated, passed and failed snapshots.
+//
+//	snaps.WithConfig(snaps.DeleteObsolete()).Clean(m)
+//
+// Clean returns whether obsolete snapshots were found.
+func (c *Config) Clean(m *testing.M) (bool, error) {
+	_ = m // only makes sure Clean is called from TestMain
+
+	obsolete, err := defaultRegistry.obsoleteSnapshots(isFilteredRun())
+	if err != nil {
+		return false, err
+	}
+
+	found := len(obsolete) > 0
+	var removed []obsoleteSnapshot
+	shouldDelete := c.shouldDeleteObsolete()
+	if shouldDelete {
+		if removed, obsolete, err = defaultRegistry.deleteSnapshots(obsolete); err != nil {
+			return false, err
+		}
+	}
+
+	var errs []error
+	if c.IsCI() {
+		switch c.CI().Obsolete {
+		case CIIgnore:
+			obsolete = nil
+		case CIFail:
+			if len(obsolete) > 0 {
+				errs = append(errs, fmt.Errorf("%w: %d", errObsoleteSnapshots, len(obsolete)))
+			}
+		}
+	}
+
+	fmt.Print(defaultRegistry.summary(obsolete, removed, shouldDelete))
+
+	if c.IsCI() && c.CI().Pending != CIIgnore {
+		pending, err := defaultRegistry.pendingFiles()
+		if err != nil {
+			return false, err
+		}
+		if len(pending) > 0 {
+			fmt.Print(pendingSummary(pending))
+			if c.CI().Pending != CIWarn {
+				errs = append(errs, fmt.Errorf("%w: %d", errPendingSnapshots, len(pending)))
+			}
+		}
+	}
+
+	if path := c.Patch(); path != "" {
+		n, err := defaultRegistry.writePatch(path, sourceRoot())
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			colors.Fprint(os.Stdout, colors.Dim, fmt.Sprintf("Failing snapshots of %d file(s) written to %s, to apply them run `git apply %s` from the module root\n\n", n, path, path))
+		}
+	}
+
+	return found, errors.Join(errs...)
+}
+
+func (c *Config) shouldDeleteObsolete() bool {
+	if c.IsCI() {
+		return c.CI().Obsolete == CIUpdate
+	}
+
+	return c.DeleteObsolete() || "clean" == updateVAR
+}
+
+// isFilteredRun reports whether the test binary runs a subset of tests e.g. `go test -run TestFoo`
+func isFilteredRun() bool {
+	for _, name := range []string{"test.run", "test.skip"} {
+		if f := flag.Lookup(name); f != nil && f.Value.String() != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// obsoleteSnapshot is either a whole snapshot file or a section of a FilePerTestFile snapshot file
+type obsoleteSnapshot struct {
+	path    string
+	section string
+}
+
+func (r *snapRegistry) obsoleteSnapshots(filtered bool) ([]obsoleteSnapshot, error) {
+	files, err := r.obsoleteFiles(filtered)
+	if err != nil {
+		return nil, err
+	}
+	sections, err := r.obsoleteSections(filtered)
+	if err != nil {
+		return nil, err
+	}
+
+	obsolete := make([]obsoleteSnapshot, 0, len(files)+len(sections))
+	for _, path := range files {
+		obsolete = append(obsolete, obsoleteSnapshot{path: path})
+	}
+
+	return append(obsolete, sections...), nil
+}
+
+// deleteSnapshots removes the obsolete snapshots and returns the ones removed and the ones left in place,
+// e.g. files read in place with `OutputDir` are never deleted
+func (r *snapRegistry) deleteSnapshots(obsolete []obsoleteSnapshot) ([]obsoleteSnapshot, []obsoleteSnapshot, error) {
+	r.registryMutex.Lock()
+	defer r.registryMutex.Unlock()
+
+	files := make([]string, 0, len(obsolete))
+	sections := make(map[string]map[string]bool)
+	for _, o := range obsolete {
+		if o.section == "" {
+			files = append(files, o.path)
+			continue
+		}
+		if sections[o.path] == nil {
+			sections[o.path] = make(map[string]bool)
+		}
+		sections[o.path][o.section] = true
+	}
+
+	for path, ids := range sections {
+		if err := removeSections(r.store(filepath.Dir(path)), path, ids); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	deleted, err := r.deleteFiles(files)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	removed := make([]obsoleteSnapshot, 0, len(obsolete))
+	kept := make([]obsoleteSnapshot, 0)
+	for _, o := range obsolete {
+		if o.section == "" && !deleted[o.path] {
+			kept = append(kept, o)
+			continue
+		}
+		removed = append(removed, o)
+	}
+
+	return removed, kept, nil
+}
+
+// obsoleteFiles walks every snapshot dir touched during the run, and every dir nested in the ones
+// a `FilenameTemplate` was used in, and returns the snapshot files no test produced.
+//
+// When the run is filtered e.g. `go test -run TestFoo` only numbered snapshots of tests that
+// actually ran are considered, as there is no way to know if the rest are still in use.
+func (r *snapRegistry) obsoleteFiles(filtered bool) ([]string, error) {
+	r.skippedTestsMutex.Lock()
+	skipped := append([]string(nil), r.skippedTests...)
+	skippedFiles := make(map[string]int, len(r.skippedFiles))
+	for k, v := range r.skippedFiles {
+		skippedFiles[k] = v
+	}
+	r.skippedTestsMutex.Unlock()
+
+	r.registryMutex.Lock()
+	defer r.registryMutex.Unlock()
+
+	dirs, err := r.snapshotDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	obsolete := make([]string, 0)
+	for dir, extensions := range dirs {
+		names, err := r.store(dir).List(dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if r.registryCleanup[path] > 0 || !hasSnapshotExtension(name, extensions) {
+				continue
+			}
+			if belongsToTests(name, skipped) || skippedFiles[strings.TrimSuffix(name, filepath.Ext(name))] > 0 ||
+				r.producedBySkippedTests(path, skipped) {
+				continue
+			}
+			if filtered && !producedByTests(name, extensions, r.registryTests) {
+				continue
+			}
+
+			obsolete = append(obsolete, path)
+		}
+	}
+	sort.Strings(obsolete)
+
+	return obsolete, nil
+}
+
+// pendingFiles returns the snapshots waiting for review in every snapshot dir touched during the run
+func (r *snapRegistry) pendingFiles() ([]string, error) {
+	r.registryMutex.Lock()
+	defer r.registryMutex.Unlock()
+
+	pending := make([]string, 0)
+	for dir := range r.registryDirs {
+		names, err := r.store(dir).List(dir)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, name := range names {
+			if strings.HasSuffix(name, pendingExtension) {
+				pending = append(pending, filepath.Join(dir, name))
+			}
+		}
+	}
+	sort.Strings(pending)
+
+	return pending, nil
+}
+
+// obsoleteSections parses every FilePerTestFile snapshot file touched during the run and
+// returns the sections no test produced, following the same rules as obsoleteFiles.
+func (r *snapRegistry) obsoleteSections(filtered bool) ([]obsoleteSnapshot, error) {
+	r.skippedTestsMutex.Lock()
+	skipped := append([]string(nil), r.skippedTests...)
+	r.skippedTestsMutex.Unlock()
+
+	r.registryMutex.Lock()
+	defer r.registryMutex.Unlock()
+
+	obsolete := make([]obsoleteSnapshot, 0)
+	for path, used := range r.registrySections {
+		multiSnapshotMutex.Lock()
+		sections, err := readSections(r.store(filepath.Dir(path)), path)
+		multiSnapshotMutex.Unlock()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, section := range sections {
+			test := sectionTest(section.id)
+			if used[section.id] > 0 || isSkippedTest(test, skipped) || (filtered && r.registryTests[test] == 0) {
+				continue
+			}
+
+			obsolete = append(obsolete, obsoleteSnapshot{path: path, section: section.id})
+		}
+	}
+	sort.Slice(obsolete, func(i, j int) bool {
+		if obsolete[i].path != obsolete[j].path {
+			return obsolete[i].path < obsolete[j].path
+		}
+		return obsolete[i].section < obsolete[j].section
+	})
+
+	return obsolete, nil
+}
+
+// isSkippedTest reports whether the test or one of its parents was skipped
+func isSkippedTest(name string, skipped []string) bool {
+	for _, s := range skipped {
+		if name == s || strings.HasPrefix(name, s+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasSnapshotExtension(name string, extensions map[string]int) bool {
+	for ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// belongsToTests reports whether the snapshot file was produced by one of the tests or their subtests
+//
+// Names truncated by sanitizeFilename only keep the start of the test name, those belong to the
+// tests whose name starts with what is left of it.
+func belongsToTests(filename string, tests []string) bool {
+	truncated := truncatedFilename.FindStringSubmatch(filename)
+	for _, name := range tests {
+		if strings.HasPrefix(filename, testFilename(name)+"_") {
+			return true
+		}
+		prefix := escapeFilename(strings.ReplaceAll(name, "/", "_") + "_")
+		if strings.HasPrefix(filename, prefix) || (truncated != nil && strings.HasPrefix(prefix, truncated[1])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// truncatedFilename matches snapshot file names truncated by sanitizeFilename, capturing what was kept
+var truncatedFilename = regexp.MustCompile(`^(.+)~[0-9a-f]{8}_`)
+
+// producedByTests reports whether the snapshot file has the form <TestName>_<n><ext> for one of the tests,
+// excluding snapshots of their subtests
+func producedByTests(filename string, extensions, tests map[string]int) bool {
+	for ext := range extensions {
+		name, ok := strings.CutSuffix(filename, ext)
+		if !ok {
+			continue
+		}
+		i := strings.LastIndexByte(name, '_')
+		if i == -1 {
+			continue
+		}
+		if _, err := strconv.Atoi(name[i+1:]); err != nil {
+			continue
+		}
+		for test := range tests {
+			if testFilename(test) == name[:i] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// deleteFiles removes the files along with the snapshot dirs left empty and returns the files actually removed,
+// callers must hold registryMutex
+func (r *snapRegistry) deleteFiles(files []string) (map[string]bool, error) {
+	deleted := make(map[string]bool, len(files))
+	dirs := make(map[string]struct{})
+	for _, path := range files {
+		if err := r.store(filepath.Dir(path)).Delete(path); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		deleted[path] = true
+		dirs[filepath.Dir(path)] = struct{}{}
+	}
+
+	for dir := range dirs {
+		store := r.store(dir)
+		for {
+			if names, err := store.List(dir); err != nil || len(names) > 0 {
+				break
+			}
+			if store.Delete(dir) != nil { // dirs still containing nested dirs are kept
+				break
+			}
+			// dirs nested by templates are removed up to the template dir
+			if dir = filepath.Dir(dir); !r.inTemplateDir(dir) {
+				break
+			}
+		}
+	}
+
+	return deleted, nil
+}
+
+// store returns the store snapshots in dir were written with, callers must hold registryMutex
+func (r *snapRegistry) store(dir string) Store {
+	if store, ok := r.registryStores[dir]; ok {
+		return store
+	}
+
+	return NewDiskStore()
+}
+
+// summary prints the counts of the run events along with the obsolete snapshots, the removed ones
+// and the ones still in place. deleted reports whether removing obsolete snapshots was requested.
+func (r *snapRegistry) summary(obsolete, removed []obsoleteSnapshot, deleted bool) string {
+	r.testEventsMutex.Lock()
+	events := make(map[uint8]int, len(r.testEvents))
+	for k, v := range r.testEvents {
+		events[k] = v
+	}
+	r.testEventsMutex.Unlock()
+
+	r.skippedTestsMutex.Lock()
+	skipped := len(r.skippedTests)
+	r.skippedTestsMutex.Unlock()
+
+	if len(events) == 0 && skipped == 0 && len(obsolete) == 0 && len(removed) == 0 {
+		return ""
+	}
+
+	r.registryMutex.Lock()
+	modes := make([]string, 0, len(r.registryModes))
+	for m := range r.registryModes {
+		modes = append(modes, m.String())
+	}
+	r.registryMutex.Unlock()
+	sort.Strings(modes)
+
+	var s strings.Builder
+	colors.Fprint(&s, colors.BoldWhite, "\nSnapshot Summary\n\n")
+	if len(modes) > 0 {
+		colors.Fprint(&s, colors.Dim, fmt.Sprintf("update mode: %s\n\n", strings.Join(modes, ", ")))
+	}
+
+	printEvent(&s, colors.Green, symbols.SuccessSymbol, "snapshot", "passed", events[passed])
+	printEvent(&s, colors.Green, symbols.UpdateSymbol, "snapshot", "added", events[added])
+	printEvent(&s, colors.Green, symbols.UpdateSymbol, "snapshot", "updated", events[updated])
+	printEvent(&s, colors.Red, symbols.ErrorSymbol, "snapshot", "failed", events[erred])
+	printEvent(&s, colors.Yellow, symbols.UpdateSymbol, "snapshot", "pending review", events[pending])
+	printEvent(&s, colors.Yellow, symbols.InfoSymbol, "snapshot", "failed with a warning", events[warned])
+	printEvent(&s, colors.Dim, symbols.InfoSymbol, "snapshot", "failed and ignored", events[ignored])
+	printEvent(&s, colors.Yellow, symbols.SkipSymbol, "test", "skipped", skipped)
+	printEvent(&s, colors.Green, symbols.SuccessSymbol, "obsolete snapshot", "removed", len(removed))
+	printObsolete(&s, removed)
+	printEvent(&s, colors.Yellow, symbols.InfoSymbol, "snapshot", "obsolete", len(obsolete))
+	printObsolete(&s, obsolete)
+	if len(obsolete) > 0 && deleted {
+		colors.Fprint(&s, colors.Dim, "\nSnapshots read in place with OutputDir are never removed, delete them from the source tree\n")
+	} else if len(obsolete) > 0 {
+		colors.Fprint(&s, colors.Dim, "\nTo remove obsolete snapshots run tests with UPDATE_SNAPS=clean\n")
+	}
+	if events[pending] > 0 {
+		colors.Fprint(&s, colors.Dim, "\nTo review pending snapshots run `go run github.com/KoNekoD/go-snaps/cmd/go-snaps review`\n")
+	}
+	s.WriteByte('\n')
+
+	return s.String()
+}
+
+func printObsolete(s *strings.Builder, obsolete []obsoleteSnapshot) {
+	cwd, _ := os.Getwd()
+	for _, o := range obsolete {
+		path := o.path
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+		if o.section != "" {
+			path += " [" + o.section + "]"
+		}
+		colors.Fprint(s, colors.Dim, fmt.Sprintf("  %s%s\n", symbols.EnterSymbol, path))
+	}
+}
+
+func pendingSummary(pending []string) string {
+	var s strings.Builder
+	printEvent(&s, colors.Yellow, symbols.UpdateSymbol, "snapshot", "left pending review", len(pending))
+
+	cwd, _ := os.Getwd()
+	for _, path := range pending {
+		if rel, err := filepath.Rel(cwd, path); err == nil {
+			path = rel
+		}
+		colors.Fprint(&s, colors.Dim, fmt.Sprintf("  %s%s\n", symbols.EnterSymbol, path))
+	}
+	s.WriteByte('\n')
+
+	return s.String()
+}
+
+func printEvent(w io.Writer, color, symbol, subject, verb string, n int) {
+	if n == 0 {
+		return
+	}
+	if n > 1 {
+		subject += "s"
+	}
+
+	colors.Fprint(w, color, fmt.Sprintf("%s%d %s %s\n", symbol, n, subject, verb))
+}
@@ -0,0 +1,185 @@
+package snaps
+
+import (
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"github.com/KoNekoD/go-snaps/snaps/colors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func setupSnapsDir(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		_ = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+		_ = os.WriteFile(path, []byte("mock-snapshot"), os.ModePerm)
+	}
+
+	return dir
+}
+
+func TestObsoleteFiles(t *testing.T) {
+	t.Run("should report files no test produced", func(t *testing.T) {
+		dir := setupSnapsDir(t, "TestA_1.snap", "TestA_2.snap", "TestB_1.snap", "TestB_1.json", "notes.txt")
+		r := newSnapRegistry()
+		r.registryDirs[dir] = map[string]int{".snap": 1}
+		r.registryCleanup[filepath.Join(dir, "TestA_1.snap")] = 1
+
+		obsolete, err := r.obsoleteFiles(false)
+
+		test.NoError(t, err)
+		test.Equal(t, []string{filepath.Join(dir, "TestA_2.snap"), filepath.Join(dir, "TestB_1.snap")}, obsolete)
+	})
+
+	t.Run("should ignore skipped tests and their subtests", func(t *testing.T) {
+		dir := setupSnapsDir(t, "TestA_1.snap", "TestA_sub_1.snap", "TestAB_1.snap")
+		r := newSnapRegistry()
+		r.registryDirs[dir] = map[string]int{".snap": 1}
+		r.skippedTests = append(r.skippedTests, "TestA")
+
+		obsolete, err := r.obsoleteFiles(false)
+
+		test.NoError(t, err)
+		test.Equal(t, []string{filepath.Join(dir, "TestAB_1.snap")}, obsolete)
+	})
+
+	t.Run("should only consider tests that ran on filtered runs", func(t *testing.T) {
+		dir := setupSnapsDir(t, "TestA_1.snap", "TestA_2.snap", "TestB_1.snap")
+		r := newSnapRegistry()
+		r.registryDirs[dir] = map[string]int{".snap": 1}
+		r.registryCleanup[filepath.Join(dir, "TestA_1.snap")] = 1
+		r.registryTests["TestA"] = 1
+
+		obsolete, err := r.obsoleteFiles(true)
+
+		test.NoError(t, err)
+		test.Equal(t, []string{filepath.Join(dir, "TestA_2.snap")}, obsolete)
+	})
+
+	t.Run("should never consider subtests of tests that ran on filtered runs", func(t *testing.T) {
+		dir := setupSnapsDir(t, "TestA_2.snap", "TestA_sub_1.snap")
+		r := newSnapRegistry()
+		r.registryDirs[dir] = map[string]int{".snap": 1}
+		r.registryTests["TestA"] = 1
+
+		obsolete, err := r.obsoleteFiles(true)
+
+		test.NoError(t, err)
+		test.Equal(t, []string{filepath.Join(dir, "TestA_2.snap")}, obsolete)
+	})
+
+	t.Run("should ignore missing dirs", func(t *testing.T) {
+		r := newSnapRegistry()
+		r.registryDirs[filepath.Join(t.TempDir(), "missing")] = map[string]int{".snap": 1}
+
+		obsolete, err := r.obsoleteFiles(false)
+
+		test.NoError(t, err)
+		test.Equal(t, 0, len(obsolete))
+	})
+}
+
+func TestSummary(t *testing.T) {
+	t.Cleanup(func() {
+		colors.NOCOLOR = false
+	})
+	colors.NOCOLOR = true
+
+	t.Run("should print nothing when there are no events", func(t *testing.T) {
+		test.Equal(t, "", newSnapRegistry().summary(nil, nil, false))
+	})
+
+	t.Run("should print counts and obsolete files", func(t *testing.T) {
+		r := newSnapRegistry()
+		r.testEvents[passed] = 3
+		r.testEvents[added] = 1
+		r.testEvents[erred] = 2
+		r.testEvents[ignored] = 1
+		r.skippedTests = append(r.skippedTests, "TestSkipped")
+		r.registryModes[updateMismatches] = 2
+		r.registryModes[UpdateFailing] = 1
+
+		s := r.summary([]obsoleteSnapshot{{path: "/mock/__snapshots__/TestOld_1.snap"}}, nil, false)
+
+		test.Contains(t, s, "Snapshot Summary")
+		test.Contains(t, s, "update mode: failing, new+failing\n")
+		test.Contains(t, s, "✓ 3 snapshots passed\n")
+		test.Contains(t, s, "✎ 1 snapshot added\n")
+		test.Contains(t, s, "✕ 2 snapshots failed\n")
+		test.Contains(t, s, "ℹ 1 snapshot failed and ignored\n")
+		test.Contains(t, s, "⟳ 1 test skipped\n")
+		test.Contains(t, s, "ℹ 1 snapshot obsolete\n")
+		test.Contains(t, s, "TestOld_1.snap")
+		test.Contains(t, s, "UPDATE_SNAPS=clean")
+	})
+
+	t.Run("should print pending snapshots", func(t *testing.T) {
+		r := newSnapRegistry()
+		r.testEvents[pending] = 2
+
+		s := r.summary(nil, nil, false)
+
+		test.Contains(t, s, "✎ 2 snapshots pending review\n")
+		test.Contains(t, s, "go-snaps review")
+	})
+
+	t.Run("should print removed obsolete files", func(t *testing.T) {
+		s := newSnapRegistry().summary(nil, []obsoleteSnapshot{{path: "/mock/__snapshots__/TestOld_1.snap"}}, true)
+
+		test.Contains(t, s, "✓ 1 obsolete snapshot removed\n")
+		test.Contains(t, s, "TestOld_1.snap")
+		test.False(t, strings.Contains(s, "obsolete\n"))
+		test.False(t, strings.Contains(s, "UPDATE_SNAPS=clean"))
+	})
+
+	t.Run("should print obsolete files that couldn't be removed", func(t *testing.T) {
+		s := newSnapRegistry().summary(
+			[]obsoleteSnapshot{{path: "/mock/__snapshots__/TestKept_1.snap"}},
+			[]obsoleteSnapshot{{path: "/mock/__snapshots__/TestOld_1.snap"}},
+			true,
+		)
+
+		test.Contains(t, s, "✓ 1 obsolete snapshot removed\n")
+		test.Contains(t, s, "ℹ 1 snapshot obsolete\n")
+		test.Contains(t, s, "TestKept_1.snap")
+		test.Contains(t, s, "never removed")
+	})
+}
+
+func TestDeleteObsolete(t *testing.T) {
+	t.Run("should remove files and empty dirs", func(t *testing.T) {
+		dir := setupSnapsDir(t, "a/TestA_1.snap", "b/TestB_1.snap", "b/TestB_2.snap")
+
+		deleted, err := newSnapRegistry().deleteFiles([]string{
+			filepath.Join(dir, "a", "TestA_1.snap"),
+			filepath.Join(dir, "b", "TestB_2.snap"),
+			filepath.Join(dir, "b", "TestB_3.snap"),
+		})
+
+		test.NoError(t, err)
+		test.Equal(t, map[string]bool{
+			filepath.Join(dir, "a", "TestA_1.snap"): true,
+			filepath.Join(dir, "b", "TestB_2.snap"): true,
+		}, deleted)
+		_, err = os.Stat(filepath.Join(dir, "a"))
+		test.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(dir, "b", "TestB_1.snap"))
+		test.NoError(t, err)
+	})
+
+	t.Run("should respect config, UPDATE_SNAPS and CI", func(t *testing.T) {
+		resetEnv(t)
+
+		test.False(t, defaultConfig().shouldDeleteObsolete())
+		test.True(t, WithConfig(DeleteObsolete()).shouldDeleteObsolete())
+
+		updateVAR = "clean"
+		test.True(t, defaultConfig().shouldDeleteObsolete())
+
+		isCI = true
+		test.False(t, WithConfig(DeleteObsolete()).shouldDeleteObsolete())
+	})
+}
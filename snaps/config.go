@@ -1,11 +1,29 @@
 package snaps
 
+import "reflect"
+
 type Config struct {
 	filename       string
 	snapsDir       string
 	extension      string
 	update         *bool
+	updateOnly     []string
+	mode           UpdateMode
 	sortProperties bool
+	deleteObsolete bool
+	layout         FileLayout
+	store          Store
+	header         bool
+	template       string
+	outputDir      string
+	patch          string
+	ci             *bool
+	ciPolicy       CIPolicy
+	html           HTMLOptions
+	http           HTTPOptions
+
+	serializers          map[reflect.Type]Serializer
+	serializerInterfaces []reflect.Type // interfaces with a serializer, in registration order
 }
 
 func defaultConfig() *Config { return &Config{snapsDir: "__snapshots__"} }
@@ -18,8 +36,57 @@ func (c *Config) Extension() string { return c.extension }
 
 func (c *Config) Update() *bool { return c.update }
 
+func (c *Config) UpdateOnly() []string { return c.updateOnly }
+
+func (c *Config) Mode() UpdateMode { return c.mode }
+
 func (c *Config) SortProperties() bool { return c.sortProperties }
 
+func (c *Config) DeleteObsolete() bool { return c.deleteObsolete }
+
+func (c *Config) Layout() FileLayout { return c.layout }
+
+func (c *Config) Header() bool { return c.header }
+
+func (c *Config) FilenameTemplate() string { return c.template }
+
+func (c *Config) HTML() HTMLOptions { return c.html }
+
+func (c *Config) HTTP() HTTPOptions { return c.http }
+
+// OutputDir returns the dir snapshot writes are redirected to, falling back to SNAPS_OUTPUT_DIR
+func (c *Config) OutputDir() string {
+	if c.outputDir == "" {
+		return outputDirVAR
+	}
+
+	return c.outputDir
+}
+
+// Patch returns the path of the patch of failing snapshots, falling back to SNAPS_PATCH
+func (c *Config) Patch() string {
+	if c.patch == "" {
+		return patchVAR
+	}
+
+	return c.patch
+}
+
+func (c *Config) Store() Store {
+	store := c.store
+	if store == nil {
+		store = NewDiskStore()
+		if st, ok := bazelStore(store); ok {
+			store = st
+		}
+	}
+	if dir := c.OutputDir(); dir != "" {
+		store = newMirrorStore(store, sourceRoot(), dir)
+	}
+
+	return store
+}
+
 // WithConfig Create snaps with configuration
 //
 //	snaps.WithConfig(snaps.Filename("my_test")).MatchSnapshot(t, "hello world")
@@ -38,6 +105,50 @@ func WithConfig(args ...func(*Config)) *Config {
 // It respects if running on CI.
 func Update(u bool) func(*Config) { return func(c *Config) { c.update = &u } }
 
+// UpdateOnly updates snapshots only for tests whose name matches one of the patterns,
+// mismatches of any other test still fail
+//
+//	snaps.WithConfig(snaps.UpdateOnly("TestCheckout/.*", "TestCart")).MatchSnapshot(t, "hello world")
+//
+// Patterns are regular expressions matched against the whole `t.Name()`, a test matches also when one of
+// its parents does. The same can be achieved with UPDATE_SNAPS='TestCheckout/.*,TestCart'.
+//
+// It respects if running on CI and is ignored when a `Mode` is set.
+func UpdateOnly(patterns ...string) func(*Config) {
+	return func(c *Config) {
+		u := true
+		c.update = &u
+		c.updateOnly = patterns
+	}
+}
+
+// UpdateMode determines how missing and mismatching snapshots are handled
+type UpdateMode string
+
+// Update modes, each one can also be selected by running tests with UPDATE_SNAPS=<mode> e.g. UPDATE_SNAPS=failing
+const (
+	// UpdateNone never writes snapshots, missing snapshots fail even when not running on CI
+	UpdateNone UpdateMode = "none"
+	// UpdateNew writes missing snapshots and fails on mismatches, the default when not running on CI
+	UpdateNew UpdateMode = "new"
+	// UpdateFailing rewrites mismatching snapshots, missing snapshots fail
+	UpdateFailing UpdateMode = "failing"
+	// UpdateAll writes missing snapshots, rewrites mismatching ones and passing ones stored with a
+	// different formatting e.g. json snapshots that are only semantically equal
+	UpdateAll UpdateMode = "all"
+	// UpdatePending writes missing and mismatching snapshots next to the original as `.snap.new` files
+	// and fails the test, so each change can be accepted or rejected later on.
+	UpdatePending UpdateMode = "pending"
+)
+
+// Mode determines how missing and mismatching snapshots are handled, it takes precedence over `Update`
+// and `UpdateOnly`, the mode applies to every test whether it matches the `UpdateOnly` patterns or not
+//
+//	snaps.WithConfig(snaps.Mode(snaps.UpdateFailing)).MatchSnapshot(t, "hello world")
+//
+// It respects if running on CI, where snapshots are written according to `CI`.
+func Mode(m UpdateMode) func(*Config) { return func(c *Config) { c.mode = m } }
+
 // Filename Specify folder name where snapshots are stored
 //
 //	default: __snapshots__
@@ -64,3 +175,127 @@ func Ext(ext string) func(*Config) { return func(c *Config) { c.extension = ext
 //
 // default: false
 func SortProperties() func(*Config) { return func(c *Config) { c.sortProperties = true } }
+
+// DeleteObsolete removes obsolete snapshot files when calling `Clean`
+//
+//	snaps.WithConfig(snaps.DeleteObsolete()).Clean(m)
+//
+// It has the same effect as running tests with UPDATE_SNAPS=clean.
+// On CI obsolete snapshots are only removed when the `CI` policy sets Obsolete to CIUpdate.
+func DeleteObsolete() func(*Config) { return func(c *Config) { c.deleteObsolete = true } }
+
+// FileLayout determines how snapshots are split into files
+type FileLayout uint8
+
+const (
+	// FilePerSnapshot stores every snapshot in its own file `<TestName>_<n>.snap`, the default layout
+	FilePerSnapshot FileLayout = iota
+	// FilePerTestFile stores all snapshots of a _test.go file in one `<file>_test.snap` file, with a section
+	// per snapshot named after the test and a counter e.g. `[TestFoo/case - 2]`
+	FilePerTestFile
+)
+
+// Layout Specify how snapshots are split into files
+//
+//	snaps.WithConfig(snaps.Layout(snaps.FilePerTestFile)).MatchSnapshot(t, "hello world")
+//
+// default: FilePerSnapshot
+func Layout(l FileLayout) func(*Config) { return func(c *Config) { c.layout = l } }
+
+// Storage Specify where snapshots are read from and written to
+//
+//	snaps.WithConfig(snaps.Storage(snaps.NewMemoryStore())).MatchSnapshot(t, "hello world")
+//
+// default: NewDiskStore(), see also `NewFSStore` for snapshots embedded in the test binary.
+//
+// Under Bazel the default store reads snapshots from runfiles and writes them to the same path in the
+// workspace for `bazel run` (BUILD_WORKSPACE_DIRECTORY) or in TEST_UNDECLARED_OUTPUTS_DIR for `bazel test`.
+func Storage(st Store) func(*Config) { return func(c *Config) { c.store = st } }
+
+// Header writes a header at the top of each snapshot with the test name, the caller, the snapshot format
+// and the go-snaps version, see `SnapshotHeader`
+//
+//	snaps.WithConfig(snaps.Header()).MatchSnapshot(t, "hello world")
+//
+// The header is ignored when comparing snapshots, so snapshots with and without header still match.
+func Header() func(*Config) { return func(c *Config) { c.header = true } }
+
+// FilenameTemplate Specify how snapshot files are named, relative to the snapshots dir and without extension
+//
+//	snaps.WithConfig(snaps.FilenameTemplate("{file}/{test}/{subtest}_{n}")).MatchSnapshot(t, "hello world")
+//	// __snapshots__/handler_test/TestFoo/case_1.snap
+//
+// Tokens:
+//   - {package} the name of the dir containing the test file
+//   - {file} the test file name e.g. handler_test
+//   - {test} the top-level test name e.g. TestFoo
+//   - {subtest} the subtest name e.g. case, nested subtests become nested dirs, empty for top-level tests
+//   - {n} the snapshot number within the test
+//   - {name} the snapshot name, see `MatchNamed`
+//
+// {n} and {name} are interchangeable, named snapshots replace both with their name and numbered snapshots
+// with their number. When neither is used `_{n}` is appended.
+//
+// `Filename` takes precedence, the FilePerTestFile layout doesn't use templates.
+func FilenameTemplate(tmpl string) func(*Config) { return func(c *Config) { c.template = tmpl } }
+
+// OutputDir redirects snapshot writes to dir, new, updated and pending snapshots are written to the same path
+// relative to the module root under dir while snapshots are still read from their usual location
+//
+//	snaps.WithConfig(snaps.OutputDir("/tmp/snaps")).MatchSnapshot(t, "hello world")
+//	// reads  <module>/pkg/__snapshots__/TestFoo_1.snap
+//	// writes /tmp/snaps/pkg/__snapshots__/TestFoo_1.snap
+//
+// It has the same effect as running tests with SNAPS_OUTPUT_DIR=/tmp/snaps, useful when the source tree is
+// read-only e.g. mounted in a container. Snapshots outside the module are written in place.
+func OutputDir(dir string) func(*Config) { return func(c *Config) { c.outputDir = dir } }
+
+// Patch writes the snapshots that failed without being updated, missing or mismatching, to a patch at path
+// when `Clean` runs. Paths in the patch are relative to the module root so it applies from there
+//
+//	func TestMain(m *testing.M) {
+//		v := m.Run()
+//		snaps.WithConfig(snaps.Patch("/tmp/snaps.patch")).Clean(m)
+//		os.Exit(v)
+//	}
+//
+//	git apply /tmp/snaps.patch
+//
+// It has the same effect as running tests with SNAPS_PATCH=/tmp/snaps.patch, useful on CI where snapshots
+// aren't updated, the patch can be uploaded as an artifact. Test binaries of different packages can share
+// the patch, each one replaces the files of its own snapshots and the patch is removed once it's empty.
+// Failing inline snapshots are written to the patch as changes of the test sources calling `MatchInline`.
+func Patch(path string) func(*Config) { return func(c *Config) { c.patch = path } }
+
+// CI Specify what happens on CI for missing, mismatching, obsolete and pending snapshots
+//
+//	// nightly job, accept new snapshots but fail on mismatches
+//	snaps.WithConfig(snaps.CI(snaps.CIPolicy{Missing: snaps.CIUpdate})).MatchSnapshot(t, "hello world")
+//
+// Obsolete and pending snapshots are handled by `Clean`, which returns an error when the policy fails them
+//
+//	func TestMain(m *testing.M) {
+//		v := m.Run()
+//		if _, err := snaps.WithConfig(snaps.CI(snaps.CIPolicy{Obsolete: snaps.CIFail})).Clean(m); err != nil && v == 0 {
+//			v = 1
+//		}
+//		os.Exit(v)
+//	}
+func CI(policy CIPolicy) func(*Config) { return func(c *Config) { c.ciPolicy = policy } }
+
+// RunningOnCI overrides CI detection, for environments it gets wrong
+//
+//	snaps.WithConfig(snaps.RunningOnCI(true)).MatchSnapshot(t, "hello world")
+//
+// It has the same effect as running tests with SNAPS_CI=true or SNAPS_CI=false.
+func RunningOnCI(on bool) func(*Config) { return func(c *Config) { c.ci = &on } }
+
+// HTML Specify what `MatchHTML` removes before taking the snapshot
+//
+//	snaps.WithConfig(snaps.HTML(snaps.HTMLOptions{StripScripts: true, StripNonces: true})).MatchHTML(t, page)
+func HTML(opts HTMLOptions) func(*Config) { return func(c *Config) { c.html = opts } }
+
+// HTTP Specify which headers `MatchHTTPResponse` redacts or leaves out
+//
+//	snaps.WithConfig(snaps.HTTP(snaps.HTTPOptions{RedactHeaders: []string{"X-Session"}})).MatchHTTPResponse(t, resp)
+func HTTP(opts HTTPOptions) func(*Config) { return func(c *Config) { c.http = opts } }
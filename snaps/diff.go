@@ -200,6 +200,18 @@ func singlelineDiff(expected, received string) (string, int, int) {
 	return a.String(), inserted, deleted
 }
 
+// buildPrettyDiff picks the diff presentation for the snapshots and returns the complete report,
+// empty if there are no differences
+func buildPrettyDiff(expected, received, name string, line int) string {
+	differ := getUnifiedDiff
+	if shouldPrintHighlights(expected, received) {
+		differ = singlelineDiff
+	}
+	finalDiff, i, d := differ(expected, received)
+
+	return buildDiffReport(i, d, finalDiff, name, line)
+}
+
 /*
 buildDiffReport creates a report with diffs it contains a header the diff body and a footer
 
@@ -0,0 +1,20 @@
+package snaps
+
+import (
+	"testing"
+)
+
+// resetEnv runs the test as outside CI without UPDATE_SNAPS, SNAPS_OUTPUT_DIR, SNAPS_PATCH or SNAPS_CI,
+// restoring the previous values once it's done
+func resetEnv(t *testing.T) {
+	t.Helper()
+	ci, update, outputDir, patch, ciEnv := isCI, updateVAR, outputDirVAR, patchVAR, ciVAR
+	t.Cleanup(func() {
+		isCI, updateVAR, outputDirVAR, patchVAR, ciVAR = ci, update, outputDir, patch, ciEnv
+	})
+	isCI = false
+	updateVAR = ""
+	outputDirVAR = ""
+	patchVAR = ""
+	ciVAR = ""
+}
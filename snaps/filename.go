@@ -0,0 +1,142 @@
+package snaps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFilenameLength bounds sanitized names, leaving room for the counter, extensions,
+// the pending extension and temp files within the usual 255 bytes limit
+const maxFilenameLength = 180
+
+// unsafeFilenameChars are illegal or special in file names on at least one platform,
+// `%` is included as it's the escape character
+const unsafeFilenameChars = `<>:"/\|?*%`
+
+var reservedFilenames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// testFilename returns the file name of snapshots of the test, subtests are joined with `_`
+func testFilename(name string) string {
+	return sanitizeFilename(strings.ReplaceAll(name, "/", "_"))
+}
+
+// sanitizeFilename maps a name to a file name legal on every platform.
+//
+// Unsafe and control characters are percent-encoded, as well as the first character of
+// Windows reserved names e.g. `%43ON`, of names starting with a dot and the last one of names ending
+// with a dot or a space. Names longer than maxFilenameLength are truncated and suffixed with `~`
+// and a short hash of the whole name, see `UnescapeFilename`.
+func sanitizeFilename(name string) string {
+	s := escapeFilename(name)
+	if len(s) <= maxFilenameLength {
+		return s
+	}
+
+	cut := maxFilenameLength - 9
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	if i := strings.LastIndexByte(s[:cut], '%'); i != -1 && i > cut-3 {
+		cut = i // don't split escape sequences
+	}
+	sum := sha256.Sum256([]byte(name))
+
+	return s[:cut] + "~" + hex.EncodeToString(sum[:4])
+}
+
+// escapeFilename is sanitizeFilename without truncation
+func escapeFilename(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		c := name[i]
+		if (r == utf8.RuneError && size == 1) || c < 0x20 || c == 0x7f || strings.IndexByte(unsafeFilenameChars, c) != -1 {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteString(name[i : i+size])
+		}
+		i += size
+	}
+	s := b.String()
+
+	if s != "" && (s[0] == '.' || reservedFilenames[strings.ToUpper(strings.SplitN(s, ".", 2)[0])]) {
+		s = fmt.Sprintf("%%%02X", s[0]) + s[1:]
+	}
+	if last := len(s) - 1; last >= 0 && (s[last] == '.' || s[last] == ' ') {
+		s = s[:last] + fmt.Sprintf("%%%02X", s[last])
+	}
+
+	return s
+}
+
+// readLegacySnapshot falls back to the snapshot stored under the name used before test names were
+// sanitized, so upgrading doesn't turn snapshots of tests with e.g. `:` or `%` in their name into
+// missing ones. Legacy snapshots are moved to the sanitized name once updated.
+func (s *snap) readLegacySnapshot(snapPath string, err error) (string, error) {
+	legacyPath := s.legacySnapshotPath(snapPath)
+	if legacyPath == "" {
+		return "", err
+	}
+	b, legacyErr := s.c.Store().Read(legacyPath)
+	if legacyErr != nil {
+		return "", err
+	}
+
+	s.legacyPath = legacyPath
+	s.registry.registryMutex.Lock()
+	s.registry.registryCleanup[legacyPath]++
+	s.registry.registryMutex.Unlock()
+
+	return string(b), nil
+}
+
+// legacySnapshotPath returns the path of the snapshot before test names were sanitized, when it differs.
+// Filenames set with `Filename` or `FilenameTemplate` and the FilePerTestFile layout aren't affected.
+func (s *snap) legacySnapshotPath(snapPath string) string {
+	if s.c.Layout() == FilePerTestFile || s.c.Filename() != "" || s.c.FilenameTemplate() != "" {
+		return ""
+	}
+
+	legacy := func(name string) string { return strings.ReplaceAll(name, "/", "_") }
+	rest, ok := strings.CutPrefix(filepath.Base(snapPath), testFilename(s.t.Name()))
+	if !ok {
+		return ""
+	}
+	if s.named {
+		if rest, ok = strings.CutPrefix(rest, "_"+testFilename(s.name)); !ok {
+			return ""
+		}
+		rest = "_" + legacy(s.name) + rest
+	}
+
+	legacyPath := filepath.Join(filepath.Dir(snapPath), legacy(s.t.Name())+rest)
+	if legacyPath == snapPath {
+		return ""
+	}
+
+	return legacyPath
+}
+
+// UnescapeFilename reverses the escaping applied to test and snapshot names when used in snapshot
+// file names e.g. `TestParse_a%3Ab_1.snap` becomes `TestParse_a:b_1.snap`.
+//
+// `/` in test names is stored as `_` and long names are truncated followed by `~` and a hash, in those
+// cases the test name can be read from the snapshot header, see `Header`.
+func UnescapeFilename(name string) string {
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		return unescaped
+	}
+
+	return name
+}
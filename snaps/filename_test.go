@@ -0,0 +1,111 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	t.Run("should escape unsafe characters", func(t *testing.T) {
+		for name, expected := range map[string]string{
+			"TestParse":                 "TestParse",
+			`a<b>c:d"e/f\g|h?i*j%k`:     "a%3Cb%3Ec%3Ad%22e%2Ff%5Cg%7Ch%3Fi%2Aj%25k",
+			"tab\there\x00\x7f":         "tab%09here%00%7F",
+			"unicode_ünïcödé_✓":         "unicode_ünïcödé_✓",
+			"invalid_\xff":              "invalid_%FF",
+			"con":                       "%63on",
+			"LPT1.txt":                  "%4CPT1.txt",
+			"CONSOLE":                   "CONSOLE",
+			".hidden":                   "%2Ehidden",
+			"..":                        "%2E%2E",
+			"trailing ":                 "trailing%20",
+			"with spaces in the middle": "with spaces in the middle",
+		} {
+			test.Equal(t, expected, sanitizeFilename(name))
+			test.Equal(t, name, UnescapeFilename(sanitizeFilename(name)))
+		}
+	})
+
+	t.Run("should truncate long names with a hash", func(t *testing.T) {
+		long := strings.Repeat("a", 300)
+		other := strings.Repeat("a", 299) + "b"
+
+		sanitized := sanitizeFilename(long)
+
+		test.Equal(t, maxFilenameLength, len(sanitized))
+		test.True(t, strings.HasPrefix(sanitized, strings.Repeat("a", 100)))
+		test.True(t, sanitized != sanitizeFilename(other))
+		test.Equal(t, sanitized, sanitizeFilename(long))
+	})
+
+	t.Run("should not split escape sequences or runes when truncating", func(t *testing.T) {
+		for _, name := range []string{strings.Repeat(":", 200), strings.Repeat("✓", 200), "a" + strings.Repeat(":", 200)} {
+			sanitized := sanitizeFilename(name)
+			prefix, _, _ := strings.Cut(sanitized, "~")
+
+			test.True(t, len(sanitized) <= maxFilenameLength)
+			test.True(t, strings.HasPrefix(name, UnescapeFilename(prefix)))
+		}
+	})
+}
+
+func TestMatchSanitizedFilename(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should store snapshots under sanitized names", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, _ := test.NewRecordingMockTestingT(t, `TestParse/input_"a:b"_or_<c>?`)
+		mockT.MockCleanup = t.Cleanup
+
+		WithConfig(Dir(dir)).MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, "hello world", test.GetFileContent(t, filepath.Join(dir, "TestParse_input_%22a%3Ab%22_or_%3Cc%3E%3F_1.snap")))
+	})
+
+	t.Run("should read snapshots stored under the legacy name", func(t *testing.T) {
+		dir := t.TempDir()
+		legacy := filepath.Join(dir, "TestParse_a:b_1.snap")
+		_ = os.WriteFile(legacy, []byte("hello world"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestParse/a:b")
+		mockT.MockCleanup = t.Cleanup
+
+		WithConfig(Dir(dir), Mode(UpdateNone)).MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, 1, defaultRegistry.registryCleanup[legacy])
+	})
+
+	t.Run("should move legacy snapshots to the sanitized name on update", func(t *testing.T) {
+		dir := t.TempDir()
+		legacy := filepath.Join(dir, "TestParse_a:b_total.snap")
+		_ = os.WriteFile(legacy, []byte("hello world"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestParse/a:b")
+		mockT.MockCleanup = t.Cleanup
+
+		WithConfig(Dir(dir), Mode(UpdateAll)).MatchNamed(mockT, "total", "hello world")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "hello world", test.GetFileContent(t, filepath.Join(dir, "TestParse_a%3Ab_total.snap")))
+		_, err := os.Stat(legacy)
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+}
+
+func TestBelongsToTests(t *testing.T) {
+	long := strings.Repeat("a", 200)
+
+	test.True(t, belongsToTests("TestA_1.snap", []string{"TestA"}))
+	test.True(t, belongsToTests("TestA_sub%3Ax_1.snap", []string{"TestA"}))
+	test.True(t, belongsToTests("TestA._sub_1.snap", []string{"TestA."}))
+	test.True(t, belongsToTests(testFilename("Test"+long)+"_1.snap", []string{"Test" + long}))
+	test.True(t, belongsToTests(testFilename("Test"+long+"/sub")+"_1.snap", []string{"Test" + long}))
+	test.True(t, belongsToTests(testFilename("TestB/"+long)+"_1.snap", []string{"TestB"}))
+	test.False(t, belongsToTests(testFilename("TestB/"+long)+"_1.snap", []string{"TestBB"}))
+	test.False(t, belongsToTests(testFilename("Test"+long+"/sub")+"_1.snap", []string{"Test" + long[:150] + "b"}))
+	test.False(t, belongsToTests("TestAB_1.snap", []string{"TestA"}))
+}
@@ -0,0 +1,110 @@
+package snaps
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const (
+	modulePath  = "github.com/KoNekoD/go-snaps"
+	headerStart = "+++ go-snaps"
+	headerEnd   = "+++"
+)
+
+// libraryVersion is the go-snaps module version the test binary was built with
+var libraryVersion = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+
+	version := ""
+	if info.Main.Path == modulePath {
+		version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil {
+			dep = dep.Replace
+		}
+		version = dep.Version
+	}
+	if version == "" {
+		return "(devel)"
+	}
+
+	return version
+})
+
+// SnapshotHeader describes what produced a snapshot, written at the top of snapshots when using `Header`
+//
+//	+++ go-snaps
+//	test: TestFoo/case
+//	caller: foo_test.go:42
+//	format: json
+//	version: v1.2.0
+//	+++
+//	{ "foo": "bar" }
+type SnapshotHeader struct {
+	Test    string
+	Caller  string
+	Format  string
+	Version string
+}
+
+func (h SnapshotHeader) String() string {
+	return fmt.Sprintf("%s\ntest: %s\ncaller: %s\nformat: %s\nversion: %s\n%s\n",
+		headerStart, h.Test, h.Caller, h.Format, h.Version, headerEnd)
+}
+
+// ParseHeader splits a stored snapshot into its header and content,
+// ok is false when the snapshot has no header and content is returned as is.
+func ParseHeader(snapshot string) (header SnapshotHeader, content string, ok bool) {
+	rest, found := strings.CutPrefix(snapshot, headerStart+"\n")
+	if !found {
+		return SnapshotHeader{}, snapshot, false
+	}
+
+	block, content, found := strings.Cut(rest, "\n"+headerEnd+"\n")
+	if !found {
+		return SnapshotHeader{}, snapshot, false
+	}
+
+	for _, line := range strings.Split(block, "\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "test":
+			header.Test = value
+		case "caller":
+			header.Caller = value
+		case "format":
+			header.Format = value
+		case "version":
+			header.Version = value
+		}
+	}
+
+	return header, content, true
+}
+
+// withHeader prefixes the snapshot with its header if enabled
+func (s *snap) withHeader(snapshot string) string {
+	if !s.c.Header() {
+		return snapshot
+	}
+
+	file, line := s.baseCallerLine(1)
+	header := SnapshotHeader{
+		Test:    s.t.Name(),
+		Caller:  fmt.Sprintf("%s:%d", filepath.Base(file), line),
+		Format:  s.format,
+		Version: libraryVersion(),
+	}
+
+	return header.String() + snapshot
+}
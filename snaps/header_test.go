@@ -0,0 +1,81 @@
+package snaps
+
+import (
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	t.Run("should parse header and content", func(t *testing.T) {
+		header := SnapshotHeader{Test: "TestFoo/case", Caller: "foo_test.go:42", Format: "json", Version: "v1.2.0"}
+
+		parsed, content, ok := ParseHeader(header.String() + "{\n \"foo\": \"bar\"\n}")
+
+		test.True(t, ok)
+		test.Equal(t, header, parsed)
+		test.Equal(t, "{\n \"foo\": \"bar\"\n}", content)
+	})
+
+	t.Run("should return snapshots without header as is", func(t *testing.T) {
+		for _, snapshot := range []string{"hello world", "+++ go-snaps\nnot terminated", ""} {
+			_, content, ok := ParseHeader(snapshot)
+
+			test.False(t, ok)
+			test.Equal(t, snapshot, content)
+		}
+	})
+}
+
+func TestMatchWithHeader(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should write header and ignore it when comparing", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir), Header())
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestHeader")
+
+		c.MatchJSON(mockT, `{"foo":"bar"}`)
+
+		header, content, ok := ParseHeader(test.GetFileContent(t, filepath.Join(dir, "TestHeader_1.json")))
+		test.True(t, ok)
+		test.Equal(t, "TestHeader", header.Test)
+		test.True(t, strings.HasPrefix(header.Caller, "header_test.go:"))
+		test.Equal(t, "json", header.Format)
+		test.Equal(t, "{\n \"foo\": \"bar\"\n}", content)
+
+		c.MatchJSON(mockT, `{"foo":"bar"}`)
+		WithConfig(Dir(dir)).MatchJSON(mockT, `{"foo":"bar"}`)
+		test.Equal(t, 0, len(*errs))
+	})
+
+	t.Run("should match snapshots without header", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestHeader")
+
+		WithConfig(Dir(dir)).MatchSnapshot(mockT, "hello world")
+		WithConfig(Dir(dir), Header()).MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "hello world", test.GetFileContent(t, filepath.Join(dir, "TestHeader_1.snap")))
+	})
+
+	t.Run("should add header to sections", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir), Header(), Layout(FilePerTestFile))
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestHeader")
+
+		c.MatchSnapshot(mockT, "hello world")
+		c.MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, 0, len(*errs))
+		sections, err := readSections(NewDiskStore(), filepath.Join(dir, "header_test.snap"))
+		test.NoError(t, err)
+		test.Equal(t, 1, len(sections))
+		header, content, ok := ParseHeader(sections[0].content)
+		test.True(t, ok)
+		test.Equal(t, "pretty", header.Format)
+		test.Equal(t, "hello world", content)
+	})
+}
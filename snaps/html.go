@@ -0,0 +1,356 @@
+package snaps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/snaps/colors"
+	"github.com/KoNekoD/go-snaps/snaps/diff"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var errInvalidHTML = errors.New("invalid html")
+
+// HTMLOptions determines what `MatchHTML` removes before taking the snapshot
+type HTMLOptions struct {
+	StripScripts  bool // removes <script> elements
+	StripComments bool // removes comments
+	StripNonces   bool // removes nonce attributes, generated on each request for Content-Security-Policy
+}
+
+func (s *snap) matchHTML(input any) {
+	s.fileExtension = ".html"
+	s.format = "html"
+	s.t.Helper()
+
+	var b []byte
+	switch h := input.(type) {
+	case string:
+		b = []byte(h)
+	case []byte:
+		b = h
+	default:
+		s.handleError(fmt.Errorf("%w: expected a string or []byte, received %T", errInvalidHTML, input))
+		return
+	}
+
+	snapshot, err := formatHTML(b, s.c.HTML())
+	if err != nil {
+		s.handleError(fmt.Errorf("%w: %w", errInvalidHTML, err))
+		return
+	}
+	s.handleSnapshot(snapshot)
+}
+
+// formatHTML parses html and writes it indented by 2 spaces, one element per line with attributes
+// sorted and whitespace collapsed. Inputs starting with a doctype or <html> are parsed as documents,
+// anything else as a fragment of the element its first tag belongs in e.g. <tbody> for a <tr>.
+func formatHTML(b []byte, opts HTMLOptions) (string, error) {
+	var nodes []*html.Node
+	if isHTMLDocument(b) {
+		doc, err := html.Parse(bytes.NewReader(b))
+		if err != nil {
+			return "", err
+		}
+		for n := doc.FirstChild; n != nil; n = n.NextSibling {
+			nodes = append(nodes, n)
+		}
+	} else {
+		context := htmlFragmentContext(b)
+		var err error
+		nodes, err = html.ParseFragment(bytes.NewReader(b), &html.Node{Type: html.ElementNode, Data: context.String(), DataAtom: context})
+		if err != nil {
+			return "", err
+		}
+		if tag, ok := droppedHTMLElement(b, nodes); ok {
+			return "", fmt.Errorf("<%s> can't be in a fragment of <%s>", tag, context)
+		}
+	}
+
+	var w strings.Builder
+	for _, n := range nodes {
+		writeHTMLNode(&w, n, opts, 0)
+	}
+
+	return strings.TrimSuffix(w.String(), "\n"), nil
+}
+
+// htmlFragmentContexts are the elements fragments starting with these tags are parsed in, <body>
+// would drop them
+var htmlFragmentContexts = map[atom.Atom]atom.Atom{
+	atom.Head: atom.Html, atom.Body: atom.Html,
+	atom.Caption: atom.Table, atom.Colgroup: atom.Table, atom.Thead: atom.Table, atom.Tbody: atom.Table, atom.Tfoot: atom.Table,
+	atom.Col: atom.Colgroup,
+	atom.Tr:  atom.Tbody,
+	atom.Td:  atom.Tr, atom.Th: atom.Tr,
+	atom.Option: atom.Select, atom.Optgroup: atom.Select,
+}
+
+// htmlFragmentContext returns the element to parse a fragment in, picked from its first tag
+func htmlFragmentContext(b []byte) atom.Atom {
+	z := html.NewTokenizer(bytes.NewReader(b))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return atom.Body
+		case html.TextToken:
+			if len(bytes.TrimSpace(z.Text())) > 0 {
+				return atom.Body
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			if context, ok := htmlFragmentContexts[atom.Lookup(name)]; ok {
+				return context
+			}
+			return atom.Body
+		}
+	}
+}
+
+// droppedHTMLElement returns a tag of the html without an element in the parsed nodes, as tags out
+// of place in the context of a fragment are dropped by the parser
+func droppedHTMLElement(b []byte, nodes []*html.Node) (string, bool) {
+	var names []string
+	tags := make(map[string]int)
+	z := html.NewTokenizer(bytes.NewReader(b))
+	for tt := z.Next(); tt != html.ErrorToken; tt = z.Next() {
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			name, _ := z.TagName()
+			names = append(names, string(name))
+			tags[string(name)]++
+		}
+	}
+
+	var count func(n *html.Node)
+	count = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			tags[strings.ToLower(n.Data)]--
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			count(c)
+		}
+	}
+	for _, n := range nodes {
+		count(n)
+	}
+
+	for _, name := range names {
+		if tags[name] > 0 {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+func isHTMLDocument(b []byte) bool {
+	start := strings.ToLower(string(bytes.TrimSpace(b[:min(len(b), 512)])))
+	for strings.HasPrefix(start, "<!--") {
+		_, start, _ = strings.Cut(start, "-->")
+		start = strings.TrimSpace(start)
+	}
+
+	return strings.HasPrefix(start, "<!doctype") || strings.HasPrefix(start, "<html")
+}
+
+// rawTextElements keep their content as is
+var rawTextElements = map[atom.Atom]bool{atom.Pre: true, atom.Textarea: true, atom.Script: true, atom.Style: true}
+
+var voidElements = map[atom.Atom]bool{
+	atom.Area: true, atom.Base: true, atom.Br: true, atom.Col: true, atom.Embed: true, atom.Hr: true, atom.Img: true,
+	atom.Input: true, atom.Link: true, atom.Meta: true, atom.Source: true, atom.Track: true, atom.Wbr: true,
+}
+
+var whitespace = regexp.MustCompile(`\s+`)
+
+func writeHTMLNode(w *strings.Builder, n *html.Node, opts HTMLOptions, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch n.Type {
+	case html.DoctypeNode:
+		w.WriteString(indent + "<!DOCTYPE " + n.Data + ">\n")
+	case html.CommentNode:
+		if !opts.StripComments {
+			w.WriteString(indent + "<!-- " + strings.TrimSpace(n.Data) + " -->\n")
+		}
+	case html.TextNode:
+		if text := strings.TrimSpace(whitespace.ReplaceAllString(n.Data, " ")); text != "" {
+			w.WriteString(indent + html.EscapeString(text) + "\n")
+		}
+	case html.ElementNode:
+		if opts.StripScripts && n.DataAtom == atom.Script {
+			return
+		}
+
+		w.WriteString(indent + htmlStartTag(n, opts))
+		switch {
+		case voidElements[n.DataAtom]:
+			w.WriteString("\n")
+		case rawTextElements[n.DataAtom]:
+			var content bytes.Buffer
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if n.DataAtom == atom.Script || n.DataAtom == atom.Style {
+					content.WriteString(c.Data) // only text, not escaped
+					continue
+				}
+				_ = html.Render(&content, c)
+			}
+			w.WriteString(content.String() + "</" + n.Data + ">\n")
+		case n.FirstChild == nil:
+			w.WriteString("</" + n.Data + ">\n")
+		case n.FirstChild == n.LastChild && n.FirstChild.Type == html.TextNode:
+			text := strings.TrimSpace(whitespace.ReplaceAllString(n.FirstChild.Data, " "))
+			w.WriteString(html.EscapeString(text) + "</" + n.Data + ">\n")
+		default:
+			w.WriteString("\n")
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				writeHTMLNode(w, c, opts, depth+1)
+			}
+			w.WriteString(indent + "</" + n.Data + ">\n")
+		}
+	}
+}
+
+func htmlStartTag(n *html.Node, opts HTMLOptions) string {
+	attrs := make([]string, 0, len(n.Attr))
+	for _, attr := range n.Attr {
+		if opts.StripNonces && attr.Key == "nonce" {
+			continue
+		}
+
+		name := attr.Key
+		if attr.Namespace != "" {
+			name = attr.Namespace + ":" + attr.Key
+		}
+		if attr.Val == "" {
+			attrs = append(attrs, name)
+			continue
+		}
+		attrs = append(attrs, name+`="`+html.EscapeString(attr.Val)+`"`)
+	}
+	sort.Strings(attrs)
+
+	if len(attrs) == 0 {
+		return "<" + n.Data + ">"
+	}
+
+	return "<" + n.Data + " " + strings.Join(attrs, " ") + ">"
+}
+
+// htmlEqual reports whether the snapshot is the received html, once formatted with the current options
+func htmlEqual(snapshot, received string, opts HTMLOptions) bool {
+	formatted, err := formatHTML([]byte(snapshot), opts)
+	return err == nil && formatted == received
+}
+
+var (
+	htmlTagName = regexp.MustCompile(`^<([^\s/>!]+)`)
+	htmlID      = regexp.MustCompile(` id="([^"]*)"`)
+	htmlClass   = regexp.MustCompile(` class="([^"]*)"`)
+)
+
+// buildHTMLDiff is the diff of html snapshots followed by the selectors of the changed elements
+func buildHTMLDiff(expected, received, name string, line int) string {
+	finalDiff, inserted, deleted := getUnifiedDiff(expected, received)
+	if finalDiff == "" {
+		return ""
+	}
+
+	aLines, bLines := strings.Split(expected, "\n"), strings.Split(received, "\n")
+	seen := make(map[string]bool)
+	var changed []string
+	var ops []diff.OpCode
+	for _, group := range diff.NewMatcher(aLines, bLines).GetGroupedOpCodes(0) {
+		ops = append(ops, group...)
+	}
+	for _, op := range ops {
+		if op.Tag == diff.OpEqual {
+			continue
+		}
+		selectors := make([]string, 0, op.J2-op.J1+op.I2-op.I1)
+		for j := op.J1; j < op.J2; j++ {
+			selectors = append(selectors, htmlSelector(bLines, j))
+		}
+		for i := op.I1; i < op.I2; i++ {
+			selectors = append(selectors, htmlSelector(aLines, i))
+		}
+		for _, selector := range selectors {
+			if selector != "" && !seen[selector] {
+				seen[selector] = true
+				changed = append(changed, selector)
+			}
+		}
+	}
+
+	if len(changed) > 0 {
+		var s strings.Builder
+		colors.Fprint(&s, colors.Dim, "\nChanged elements:\n")
+		for _, selector := range changed {
+			colors.Fprint(&s, colors.Dim, "  "+selector+"\n")
+		}
+		finalDiff += s.String()
+	}
+
+	return buildDiffReport(inserted, deleted, finalDiff, name, line)
+}
+
+// htmlSelector returns the path of the element at the line of a formatted html snapshot or the element
+// containing it e.g. `html > body > main#content > p.lead`
+func htmlSelector(lines []string, i int) string {
+	var path []string
+	depth := htmlIndent(lines[i])
+	if strings.HasPrefix(strings.TrimLeft(lines[i], " "), "</") {
+		// the end tag of an element, find its start tag
+		for k := i - 1; k >= 0; k-- {
+			if _, ok := htmlLabel(lines[k]); ok && htmlIndent(lines[k]) == depth {
+				i = k
+				break
+			}
+		}
+	}
+	if label, ok := htmlLabel(lines[i]); ok {
+		path = append(path, label)
+	}
+	for k := i - 1; k >= 0 && depth > 0; k-- {
+		if d := htmlIndent(lines[k]); d < depth {
+			if label, ok := htmlLabel(lines[k]); ok {
+				path = append(path, label)
+			}
+			depth = d
+		}
+	}
+
+	for l, r := 0, len(path)-1; l < r; l, r = l+1, r-1 {
+		path[l], path[r] = path[r], path[l]
+	}
+
+	return strings.Join(path, " > ")
+}
+
+func htmlIndent(line string) int { return len(line) - len(strings.TrimLeft(line, " ")) }
+
+// htmlLabel returns the tag name, id and classes of an element start tag line
+func htmlLabel(line string) (string, bool) {
+	line = strings.TrimLeft(line, " ")
+	name := htmlTagName.FindStringSubmatch(line)
+	if name == nil {
+		return "", false
+	}
+
+	tag, _, _ := strings.Cut(line, ">")
+	label := name[1]
+	if id := htmlID.FindStringSubmatch(tag); id != nil {
+		label += "#" + id[1]
+	}
+	if class := htmlClass.FindStringSubmatch(tag); class != nil {
+		for _, c := range strings.Fields(class[1]) {
+			label += "." + c
+		}
+	}
+
+	return label, true
+}
@@ -0,0 +1,125 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatHTML(t *testing.T) {
+	t.Run("should format fragments", func(t *testing.T) {
+		snapshot, err := formatHTML([]byte(`<ul   class="menu" id="nav">
+	<li>Home</li>   <li  class="active">My
+	cart</li><li><a href="/?a=1&amp;b=2">Search</a> <input disabled="" type="text"></li></ul>`), HTMLOptions{})
+
+		test.NoError(t, err)
+		test.Equal(t, `<ul class="menu" id="nav">
+  <li>Home</li>
+  <li class="active">My cart</li>
+  <li>
+    <a href="/?a=1&amp;b=2">Search</a>
+    <input disabled type="text">
+  </li>
+</ul>`, snapshot)
+	})
+
+	t.Run("should keep table rows and options of fragments", func(t *testing.T) {
+		snapshot, err := formatHTML([]byte(`<!-- row --><tr><td>1</td><td>2</td></tr>`), HTMLOptions{})
+
+		test.NoError(t, err)
+		test.Equal(t, "<!-- row -->\n<tr>\n  <td>1</td>\n  <td>2</td>\n</tr>", snapshot)
+
+		snapshot, err = formatHTML([]byte(`<option value="a">A</option><option value="b" selected>B</option>`), HTMLOptions{})
+
+		test.NoError(t, err)
+		test.Equal(t, "<option value=\"a\">A</option>\n<option selected value=\"b\">B</option>", snapshot)
+	})
+
+	t.Run("should report elements dropped from fragments", func(t *testing.T) {
+		_, err := formatHTML([]byte(`<option>A</option><div>B</div>`), HTMLOptions{})
+
+		test.Equal(t, "<div> can't be in a fragment of <select>", err.Error())
+	})
+
+	t.Run("should format documents and strip scripts, comments and nonces", func(t *testing.T) {
+		page := []byte(`<!-- rendered at 10:00 --><!DOCTYPE html><html><head><title>Shop</title>
+<script nonce="r4nd0m">if (a < b) {}</script><style nonce="r4nd0m">p > a { color: red }</style></head>
+<body><pre>  keep
+   this</pre></body></html>`)
+
+		snapshot, err := formatHTML(page, HTMLOptions{StripScripts: true, StripComments: true, StripNonces: true})
+
+		test.NoError(t, err)
+		test.Equal(t, `<!DOCTYPE html>
+<html>
+  <head>
+    <title>Shop</title>
+    <style>p > a { color: red }</style>
+  </head>
+  <body>
+    <pre>  keep
+   this</pre>
+  </body>
+</html>`, snapshot)
+	})
+}
+
+func TestBuildHTMLDiff(t *testing.T) {
+	expected := "<main id=\"content\">\n  <p class=\"lead\">Hello</p>\n  <ul>\n    <li>a</li>\n  </ul>\n</main>"
+	received := "<main id=\"content\">\n  <p class=\"lead\">Hi</p>\n  <ul>\n    <li>a</li>\n    <li>b</li>\n  </ul>\n</main>"
+
+	diff := buildHTMLDiff(expected, received, "", 1)
+
+	test.Contains(t, diff, "Changed elements:")
+	test.Contains(t, diff, "main#content > p.lead\n")
+	test.Contains(t, diff, "main#content > ul > li\n")
+	test.Equal(t, "", buildHTMLDiff(expected, expected, "", 1))
+}
+
+func TestMatchHTML(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should write formatted html", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchHTML")
+
+		WithConfig(Dir(dir)).MatchHTML(mockT, `<p b="2" a="1">Hello</p>`)
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, `<p a="1" b="2">Hello</p>`, test.GetFileContent(t, filepath.Join(dir, "TestMatchHTML_1.html")))
+	})
+
+	t.Run("should match snapshots formatted differently", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(dir, "TestMatchHTML_1.html"), []byte(`<p   a="1">Hello</p>`), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchHTML")
+
+		WithConfig(Dir(dir)).MatchHTML(mockT, []byte(`<p a="1">
+			Hello
+		</p>`))
+
+		test.Equal(t, 0, len(*errs))
+	})
+
+	t.Run("should report changed elements", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(dir, "TestMatchHTML_1.html"), []byte("<div>\n  <p>Hello</p>\n</div>"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchHTML")
+
+		WithConfig(Dir(dir)).MatchHTML(mockT, `<div><p>Hi</p></div>`)
+
+		test.Equal(t, 1, len(*errs))
+		test.Contains(t, (*errs)[0].(string), "div > p\n")
+	})
+
+	t.Run("should report unsupported input", func(t *testing.T) {
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchHTML")
+
+		WithConfig(Dir(t.TempDir())).MatchHTML(mockT, 10)
+
+		test.Equal(t, 1, len(*errs))
+		test.True(t, errors.Is((*errs)[0].(error), errInvalidHTML))
+	})
+}
@@ -0,0 +1,164 @@
+package snaps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+var errNilResponse = errors.New("nil http response")
+
+const redacted = "<redacted>"
+
+// redactedHeaders change on every request, their values are replaced in snapshots. Caching and timing
+// headers e.g. Etag or Last-Modified are kept, they are often stable in tests and worth asserting
+var redactedHeaders = []string{
+	"Cf-Ray", "Date", "Request-Id", "Set-Cookie", "Traceparent", "Tracestate", "X-Amzn-Trace-Id", "X-Correlation-Id",
+	"X-Request-Id", "X-Trace-Id",
+}
+
+// HTTPOptions determines how `MatchHTTPResponse` records headers
+type HTTPOptions struct {
+	RedactHeaders []string // headers redacted on top of Date, Set-Cookie and request or trace ids e.g. Etag
+	IgnoreHeaders []string // headers left out of the snapshot
+}
+
+func (s *snap) matchHTTPResponse(resp *http.Response) {
+	s.t.Helper()
+	s.format = "http"
+
+	if resp == nil {
+		s.handleError(errNilResponse)
+		return
+	}
+
+	body, err := readResponseBody(resp)
+	if err != nil {
+		s.handleError(err)
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(statusLine(resp) + "\n")
+	b.WriteString(s.formatHeaders(resp.Header))
+	if len(body) > 0 {
+		b.WriteString("\n" + s.formatBody(resp.Header, body))
+	}
+
+	s.handleSnapshot(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// readResponseBody reads the body, decompressed when gzip encoded, and restores it so it can be read again
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return nil, nil
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(raw))
+
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") || len(raw) == 0 {
+		return raw, nil
+	}
+	r, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gzip body: %w", err)
+	}
+	defer r.Close()
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing gzip body: %w", err)
+	}
+
+	return body, nil
+}
+
+func statusLine(resp *http.Response) string {
+	proto := resp.Proto
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	status := resp.Status
+	if status == "" {
+		status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	return proto + " " + status
+}
+
+// formatHeaders writes a header per line sorted by name, values of volatile headers are redacted
+func (s *snap) formatHeaders(header http.Header) string {
+	redact := make(map[string]bool)
+	for _, name := range slices.Concat(redactedHeaders, s.c.HTTP().RedactHeaders) {
+		redact[http.CanonicalHeaderKey(name)] = true
+	}
+	ignore := make(map[string]bool)
+	for _, name := range s.c.HTTP().IgnoreHeaders {
+		ignore[http.CanonicalHeaderKey(name)] = true
+	}
+
+	names := make([]string, 0, len(header))
+	for name := range header {
+		if !ignore[http.CanonicalHeaderKey(name)] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		canonical := http.CanonicalHeaderKey(name)
+		for _, value := range header[name] {
+			switch {
+			case canonical == "Set-Cookie":
+				value = redactCookie(value)
+			case redact[canonical]:
+				value = redacted
+			}
+			b.WriteString(canonical + ": " + value + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// redactCookie keeps the cookie name and attributes, redacting the value and the expiry date
+func redactCookie(cookie string) string {
+	parts := strings.Split(cookie, ";")
+	for i, part := range parts {
+		name, _, ok := strings.Cut(part, "=")
+		if ok && (i == 0 || strings.EqualFold(strings.TrimSpace(name), "Expires")) {
+			parts[i] = name + "=" + redacted
+		}
+	}
+
+	return strings.Join(parts, ";")
+}
+
+// formatBody pretty prints json bodies, binary bodies are replaced by their size and checksum
+func (s *snap) formatBody(header http.Header, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) && gjson.ValidBytes(body) {
+		return s.snapshotSerializer.takeJsonSnapshot(body)
+	}
+	if !utf8.Valid(body) {
+		return fmt.Sprintf("<binary %d bytes, sha256:%x>", len(body), sha256.Sum256(body))
+	}
+
+	return string(body)
+}
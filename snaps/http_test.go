@@ -0,0 +1,108 @@
+package snaps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchHTTPResponse(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should record recorded responses", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchHTTPResponse")
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "application/json; charset=utf-8")
+		rec.Header().Set("X-Request-Id", "0f3c")
+		rec.Header().Add("Set-Cookie", "session=abc123; Path=/; Expires=Wed, 21 Oct 2026 07:28:00 GMT; HttpOnly")
+		rec.Header().Set("x-version", "1")
+		rec.Header().Set("Etag", `"v1"`)
+		rec.Header().Set("Last-Modified", "Wed, 21 Oct 2026 07:28:00 GMT")
+		rec.WriteHeader(http.StatusCreated)
+		_, _ = rec.WriteString(`{"name":"mock-user","id":10}`)
+
+		WithConfig(Dir(dir), SortProperties(), HTTP(HTTPOptions{RedactHeaders: []string{"X-Version"}})).
+			MatchHTTPRecorder(mockT, rec)
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, `HTTP/1.1 201 Created
+Content-Type: application/json; charset=utf-8
+Etag: "v1"
+Last-Modified: Wed, 21 Oct 2026 07:28:00 GMT
+Set-Cookie: session=<redacted>; Path=/; Expires=<redacted>; HttpOnly
+X-Request-Id: <redacted>
+X-Version: <redacted>
+
+{
+ "id": 10,
+ "name": "mock-user"
+}`, test.GetFileContent(t, filepath.Join(dir, "TestMatchHTTPResponse_1.snap")))
+	})
+
+	t.Run("should decompress gzip bodies and restore them", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Type", "text/plain")
+			gz := gzip.NewWriter(w)
+			_, _ = gz.Write([]byte("hello world"))
+			_ = gz.Close()
+		}))
+		t.Cleanup(server.Close)
+
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		req.Header.Set("Accept-Encoding", "gzip") // disables transparent decompression
+		resp, err := http.DefaultClient.Do(req)
+		test.NoError(t, err)
+
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchHTTPResponse")
+
+		WithConfig(Dir(dir), HTTP(HTTPOptions{IgnoreHeaders: []string{"content-length"}})).MatchHTTPResponse(mockT, resp)
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, `HTTP/1.1 200 OK
+Content-Encoding: gzip
+Content-Type: text/plain
+Date: <redacted>
+
+hello world`, test.GetFileContent(t, filepath.Join(dir, "TestMatchHTTPResponse_1.snap")))
+
+		raw, err := io.ReadAll(resp.Body)
+		test.NoError(t, err)
+		gz, err := gzip.NewReader(bytes.NewReader(raw))
+		test.NoError(t, err)
+		body, _ := io.ReadAll(gz)
+		test.Equal(t, "hello world", string(body))
+	})
+
+	t.Run("should replace binary bodies", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchHTTPResponse")
+		rec := httptest.NewRecorder()
+		rec.Header().Set("Content-Type", "image/png")
+		_, _ = rec.Write([]byte{0x89, 0x50, 0x4e, 0x47, 0xff})
+
+		WithConfig(Dir(dir)).MatchHTTPRecorder(mockT, rec)
+
+		test.Equal(t, 0, len(*errs))
+		test.Contains(
+			t,
+			test.GetFileContent(t, filepath.Join(dir, "TestMatchHTTPResponse_1.snap")),
+			"\n\n<binary 5 bytes, sha256:",
+		)
+	})
+
+	t.Run("should report nil responses", func(t *testing.T) {
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchHTTPResponse")
+
+		WithConfig(Dir(t.TempDir())).MatchHTTPResponse(mockT, nil)
+
+		test.Equal(t, []any{errNilResponse}, *errs)
+	})
+}
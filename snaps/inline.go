@@ -0,0 +1,241 @@
+package snaps
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const snapsImportPath = "github.com/KoNekoD/go-snaps/snaps"
+
+var (
+	errInlineNotFound = errors.New("could not find MatchInline call")
+	errInlineArgument = errors.New("inline snapshot must be nil or snaps.Inline with a string literal")
+	inlineRegistry    = newInlineSnapshotRegistry()
+)
+
+// InlineSnapshot is the expected value of `MatchInline`, stored as a string literal in the test source.
+//
+// A nil InlineSnapshot stands for a snapshot that wasn't recorded yet.
+type InlineSnapshot *string
+
+// Inline creates an inline snapshot for `MatchInline`
+func Inline(snapshot string) InlineSnapshot { return &snapshot }
+
+type inlineShift struct {
+	line  int
+	delta int
+}
+
+// inlineSnapshotRegistry serializes rewrites of test sources and keeps track of how many lines each rewrite
+// added or removed, as runtime.Caller keeps reporting the lines the test binary was compiled with.
+type inlineSnapshotRegistry struct {
+	shifts  map[string][]inlineShift
+	written map[string]string
+	mu      sync.Mutex
+}
+
+func newInlineSnapshotRegistry() *inlineSnapshotRegistry {
+	return &inlineSnapshotRegistry{shifts: make(map[string][]inlineShift), written: make(map[string]string)}
+}
+
+func (s *snap) matchInline(value any, snapshot InlineSnapshot) {
+	s.t.Helper()
+	file, line := s.baseCallerLine(2) // skips current func and the exported MatchInline func
+	file, err := callerFile(file)
+	if err != nil {
+		s.handleError(err)
+		return
+	}
+	s.registerInlineSource(file)
+	received, err := s.snapshotSerializer.takeSnapshot(value)
+	if err != nil {
+		s.handleError(err)
+		return
+	}
+	mode := s.updateMode()
+	s.registerUpdateMode(mode)
+
+	if snapshot == nil {
+		if !mode.writesNew() {
+			s.registerInlinePatch(file, line, received)
+			s.handleFailure(s.c.CI().Missing, errSnapNotFound)
+			return
+		}
+		if err := inlineRegistry.update(file, line, received); err != nil {
+			s.handleError(err)
+			return
+		}
+		s.t.Log(addedMsg)
+		s.registerTestEvent(added)
+		return
+	}
+
+	if *snapshot == received {
+		s.registerTestEvent(passed)
+		return
+	}
+	if !mode.writesFailing() {
+		s.registerInlinePatch(file, line, received)
+		s.handleFailure(s.c.CI().Mismatch, buildPrettyDiff(*snapshot, received, filepath.Base(file), line))
+		return
+	}
+	if err := inlineRegistry.update(file, line, received); err != nil {
+		s.handleError(err)
+		return
+	}
+	s.t.Log(updatedMsg)
+	s.registerTestEvent(updated)
+}
+
+// update rewrites the inline snapshot of the MatchInline call found at the line the test binary was compiled with
+func (r *inlineSnapshotRegistry) update(file string, line int, snapshot string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fmt.Sprintf("%s:%d", file, line)
+	if written, ok := r.written[key]; ok && written != snapshot {
+		return fmt.Errorf("inline snapshot at %s received different values in the same run", key)
+	}
+
+	current := shiftedLine(r.shifts[file], line)
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	out, err := rewriteInlineSnapshot(src, current, snapshot)
+	if err != nil {
+		return fmt.Errorf("%w at %s", err, key)
+	}
+	if err := os.WriteFile(file, out, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	r.written[key] = snapshot
+	delta := bytes.Count(out, []byte("\n")) - bytes.Count(src, []byte("\n"))
+	r.shifts[file] = append(r.shifts[file], inlineShift{line: line, delta: delta})
+
+	return nil
+}
+
+// shiftedLine returns the current line of the line the test binary was compiled with after the shifts
+func shiftedLine(shifts []inlineShift, line int) int {
+	current := line
+	for _, shift := range shifts {
+		if shift.line < line {
+			current += shift.delta
+		}
+	}
+
+	return current
+}
+
+// rewriteInlineSnapshot replaces the inline snapshot argument of the MatchInline call spanning the given line.
+//
+// Only the argument is replaced, the rest of the source keeps its formatting.
+func rewriteInlineSnapshot(src []byte, line int, snapshot string) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var call *ast.CallExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		c, ok := n.(*ast.CallExpr)
+		if !ok || call != nil || !isCallTo(c, "MatchInline") || len(c.Args) != 3 {
+			return call == nil
+		}
+		if fset.Position(c.Pos()).Line <= line && line <= fset.Position(c.End()).Line {
+			call = c
+		}
+		return call == nil
+	})
+	if call == nil {
+		return nil, errInlineNotFound
+	}
+
+	var (
+		arg         = call.Args[2]
+		replacement string
+	)
+	switch a := arg.(type) {
+	case *ast.Ident:
+		if a.Name != "nil" {
+			return nil, errInlineArgument
+		}
+		replacement = snapsQualifier(f) + "Inline(" + inlineLiteral(snapshot) + ")"
+	case *ast.CallExpr:
+		if !isCallTo(a, "Inline") || len(a.Args) != 1 {
+			return nil, errInlineArgument
+		}
+		lit, ok := a.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return nil, errInlineArgument
+		}
+		arg, replacement = lit, inlineLiteral(snapshot)
+	default:
+		return nil, errInlineArgument
+	}
+
+	start, end := fset.Position(arg.Pos()).Offset, fset.Position(arg.End()).Offset
+	out := make([]byte, 0, len(src)+len(replacement))
+	out = append(out, src[:start]...)
+	out = append(out, replacement...)
+	out = append(out, src[end:]...)
+
+	return out, nil
+}
+
+func isCallTo(c *ast.CallExpr, name string) bool {
+	switch fn := c.Fun.(type) {
+	case *ast.Ident:
+		return fn.Name == name
+	case *ast.SelectorExpr:
+		return fn.Sel.Name == name
+	}
+
+	return false
+}
+
+// snapsQualifier returns how the snaps package is referred to in the file e.g. "snaps."
+func snapsQualifier(f *ast.File) string {
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path != snapsImportPath {
+			continue
+		}
+		if imp.Name == nil {
+			return "snaps."
+		}
+		if imp.Name.Name == "." {
+			return ""
+		}
+		return imp.Name.Name + "."
+	}
+
+	// the file belongs to the snaps package itself
+	return ""
+}
+
+// inlineLiteral prefers raw string literals as they keep multiline snapshots readable
+func inlineLiteral(s string) string {
+	if strings.ContainsAny(s, "`\r\x00") || !utf8.ValidString(s) {
+		return strconv.Quote(s)
+	}
+
+	return "`" + s + "`"
+}
@@ -0,0 +1,141 @@
+package snaps
+
+import (
+	"fmt"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const mockInlineSource = `package mock
+
+import (
+	"testing"
+
+	"github.com/KoNekoD/go-snaps/snaps"
+)
+
+func TestMock(t *testing.T) {
+	snaps.MatchInline(t, "hello", nil)
+	snaps.MatchInline(t, "world", snaps.Inline("world"))
+	snaps.MatchInline(t,
+		"multiline",
+		snaps.Inline(` + "`multiline`" + `),
+	)
+}
+`
+
+func TestRewriteInlineSnapshot(t *testing.T) {
+	t.Run("should add snapshot to nil argument", func(t *testing.T) {
+		out, err := rewriteInlineSnapshot([]byte(mockInlineSource), 10, "hello")
+
+		test.NoError(t, err)
+		test.Contains(t, string(out), "snaps.MatchInline(t, \"hello\", snaps.Inline(`hello`))\n")
+	})
+
+	t.Run("should replace existing snapshot keeping formatting", func(t *testing.T) {
+		out, err := rewriteInlineSnapshot([]byte(mockInlineSource), 13, "line 1\nline 2")
+
+		test.NoError(t, err)
+		test.Contains(t, string(out), "snaps.MatchInline(t,\n\t\t\"multiline\",\n\t\tsnaps.Inline(`line 1\nline 2`),\n\t)")
+		test.Contains(t, string(out), "snaps.MatchInline(t, \"world\", snaps.Inline(\"world\"))")
+	})
+
+	t.Run("should only replace the argument", func(t *testing.T) {
+		src := "package mock\n\nfunc f() {\n\tx  :=   1\n\tMatchInline(nil, x, nil)\n}\n"
+
+		out, err := rewriteInlineSnapshot([]byte(src), 5, "1")
+
+		test.NoError(t, err)
+		test.Equal(t, "package mock\n\nfunc f() {\n\tx  :=   1\n\tMatchInline(nil, x, Inline(`1`))\n}\n", string(out))
+	})
+
+	t.Run("should quote snapshots containing backticks", func(t *testing.T) {
+		out, err := rewriteInlineSnapshot([]byte(mockInlineSource), 11, "`world`")
+
+		test.NoError(t, err)
+		test.Contains(t, string(out), "snaps.Inline(\"`world`\")")
+	})
+
+	t.Run("should respect import alias", func(t *testing.T) {
+		src := "package mock\n\nimport s \"github.com/KoNekoD/go-snaps/snaps\"\n\nfunc f() {\n\ts.MatchInline(nil, 1, nil)\n}\n"
+
+		out, err := rewriteInlineSnapshot([]byte(src), 6, "int(1)")
+
+		test.NoError(t, err)
+		test.Contains(t, string(out), "s.MatchInline(nil, 1, s.Inline(`int(1)`))")
+	})
+
+	t.Run("should return error for unsupported calls", func(t *testing.T) {
+		_, err := rewriteInlineSnapshot([]byte(mockInlineSource), 1, "hello")
+		test.Equal(t, errInlineNotFound, err)
+
+		src := "package mock\n\nfunc f() {\n\tMatchInline(nil, 1, snapshot)\n}\n"
+		_, err = rewriteInlineSnapshot([]byte(src), 4, "hello")
+		test.Equal(t, errInlineArgument, err)
+	})
+}
+
+func TestInlineRegistry(t *testing.T) {
+	t.Run("should track lines shifted by previous rewrites", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mock_test.go")
+		_ = os.WriteFile(path, []byte(mockInlineSource), 0o644)
+		r := newInlineSnapshotRegistry()
+
+		test.NoError(t, r.update(path, 10, "hello\nhello\nhello"))
+		test.NoError(t, r.update(path, 11, "world\nworld"))
+		test.NoError(t, r.update(path, 13, "multiline"))
+
+		content := test.GetFileContent(t, path)
+		test.Contains(t, content, "snaps.MatchInline(t, \"hello\", snaps.Inline(`hello\nhello\nhello`))")
+		test.Contains(t, content, "snaps.MatchInline(t, \"world\", snaps.Inline(`world\nworld`))")
+		test.Contains(t, content, "snaps.Inline(`multiline`),")
+	})
+
+	t.Run("should fail when a call site receives different values", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mock_test.go")
+		_ = os.WriteFile(path, []byte(mockInlineSource), 0o644)
+		r := newInlineSnapshotRegistry()
+
+		test.NoError(t, r.update(path, 10, "hello"))
+		test.NoError(t, r.update(path, 10, "hello"))
+		test.Contains(t, fmt.Sprint(r.update(path, 10, "world")), "received different values")
+	})
+}
+
+func TestMatchInline(t *testing.T) {
+	t.Run("should pass when value matches", func(t *testing.T) {
+		MatchInline(t, "hello world", Inline(`hello world`))
+		MatchInline(t, 10, Inline(`int(10)`))
+	})
+
+	t.Run("should fail with diff when value doesn't match", func(t *testing.T) {
+		mockT := test.NewMockTestingT(t)
+		errs := make([]any, 0)
+		mockT.MockError = func(args ...any) {
+			errs = append(errs, args...)
+		}
+
+		WithConfig(Mode(UpdateNone)).MatchInline(mockT, "hello universe", Inline(`hello world`))
+		WithConfig(Mode(UpdateNone)).MatchInline(mockT, "hello universe", nil)
+
+		test.Equal(t, 2, len(errs))
+		test.Contains(t, fmt.Sprint(errs[0]), "at inline_test.go:")
+		test.Equal[any](t, errSnapNotFound, errs[1])
+	})
+
+	t.Run("should register failures in the patch", func(t *testing.T) {
+		mockT := test.NewMockTestingT(t)
+		mockT.MockError = func(...any) {}
+
+		WithConfig(Mode(UpdateNone)).MatchInline(mockT, "hello patch", Inline(`hello world`))
+
+		file, err := filepath.Abs("inline_test.go")
+		test.NoError(t, err)
+		defaultRegistry.registryPatchesMutex.Lock()
+		entry := defaultRegistry.registryPatches[file]
+		defaultRegistry.registryPatchesMutex.Unlock()
+		test.Contains(t, entry.new, "MatchInline(mockT, \"hello patch\", Inline(`hello patch`))")
+	})
+}
@@ -0,0 +1,82 @@
+package snaps
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+)
+
+// Locker is implemented by stores able to lock a snapshot path across processes, it's used around
+// read-modify-write cycles of snapshot files shared by multiple tests.
+type Locker interface {
+	// Lock blocks until the lock of path is acquired and returns the func releasing it
+	Lock(path string) (unlock func(), err error)
+}
+
+// lockStore locks path if the store supports it. The disk store can't lock on platforms without
+// advisory locks (see lockSupported), there snapshot files are still written atomically but
+// concurrent test binaries sharing a snapshot file might lose each other's sections.
+func lockStore(store Store, path string) (func(), error) {
+	if l, ok := store.(Locker); ok {
+		return l.Lock(path)
+	}
+
+	return func() {}, nil
+}
+
+// Lock takes an advisory lock on a file in the temp dir named after path, so lock files never end up
+// in snapshot dirs. Test binaries of different packages sharing a snapshot dir run in separate processes.
+//
+// The lock file is removed on unlock, a process that opened it before is then holding a lock on a
+// removed file so it checks the lock file is still in place after acquiring it and retries otherwise.
+//
+// It doesn't lock on platforms without advisory locks.
+func (diskStore) Lock(path string) (func(), error) {
+	if !lockSupported {
+		return func() {}, nil
+	}
+
+	name := lockPath(path)
+	for {
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, filePerm)
+		if err != nil {
+			return nil, err
+		}
+		if err := lockFile(f); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		if isSameFile(f, name) {
+			return func() { releaseFile(f, name) }, nil
+		}
+
+		_ = unlockFile(f)
+		_ = f.Close()
+	}
+}
+
+// lockPath returns the lock file of path in the temp dir
+func lockPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(path))
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("go-snaps-%x.lock", h.Sum64()))
+}
+
+// isSameFile reports whether f is still the file at name
+func isSameFile(f *os.File, name string) bool {
+	opened, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(name)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(opened, current)
+}
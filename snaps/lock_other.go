@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+
+package snaps
+
+import "os"
+
+// lockSupported is false on platforms without advisory locks, the disk store doesn't lock there
+const lockSupported = false
+
+func lockFile(*os.File) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
+
+func releaseFile(f *os.File, _ string) { _ = f.Close() }
@@ -0,0 +1,31 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package snaps
+
+import (
+	"os"
+	"syscall"
+)
+
+const lockSupported = true
+
+func lockFile(f *os.File) error {
+	for {
+		err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			return err
+		}
+	}
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// releaseFile removes the lock file before unlocking it, so a process acquiring the lock afterwards
+// finds it removed and retries instead of sharing it with a process locking a new file
+func releaseFile(f *os.File, name string) {
+	_ = os.Remove(name)
+	_ = unlockFile(f)
+	_ = f.Close()
+}
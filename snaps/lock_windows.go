@@ -0,0 +1,26 @@
+//go:build windows
+
+package snaps
+
+import (
+	"golang.org/x/sys/windows"
+	"os"
+)
+
+const lockSupported = true
+
+func lockFile(f *os.File) error {
+	return windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, &windows.Overlapped{})
+}
+
+func unlockFile(f *os.File) error {
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, &windows.Overlapped{})
+}
+
+// releaseFile closes the lock file before removing it as Windows can't remove open files. The removal
+// fails while another process has it open, so it's only removed once no process is waiting on it.
+func releaseFile(f *os.File, name string) {
+	_ = unlockFile(f)
+	_ = f.Close()
+	_ = os.Remove(name)
+}
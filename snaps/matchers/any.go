@@ -2,6 +2,8 @@ package matchers
 
 import (
 	"errors"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/internal/xmltree"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -52,3 +54,32 @@ func (a *AnyMatcher) JSON(s []byte) ([]byte, []MatcherError) {
 
 	return json, errs
 }
+
+// XML replaces the text of each element or the value of each attribute at the paths with the placeholder
+func (a *AnyMatcher) XML(s []byte) ([]byte, []MatcherError) {
+	doc, err := xmltree.Parse(s)
+	if err != nil {
+		return nil, []MatcherError{{Reason: err, Matcher: a.name}}
+	}
+
+	var errs []MatcherError
+	for _, path := range a.paths {
+		matches, err := doc.Select(path)
+		if err != nil {
+			errs = append(errs, MatcherError{Reason: err, Matcher: a.name, Path: path})
+			continue
+		}
+		if len(matches) == 0 {
+			if a.errOnMissingPath {
+				errs = append(errs, MatcherError{Reason: errors.New("path does not exist"), Matcher: a.name, Path: path})
+			}
+			continue
+		}
+
+		for _, m := range matches {
+			m.Set(fmt.Sprint(a.placeholder))
+		}
+	}
+
+	return []byte(doc.Encode()), errs
+}
@@ -98,4 +98,30 @@ func TestAnyMatcher(t *testing.T) {
 			},
 		)
 	})
+
+	t.Run("XML", func(t *testing.T) {
+		x := []byte(`<user id="1f2e"><name>mock-user</name><created>16/10/2022</created></user>`)
+
+		t.Run("should return error in case of missing path", func(t *testing.T) {
+			_, errs := Any("/user/email").XML(x)
+
+			test.Equal(t, 1, len(errs))
+			test.Equal(t, "path does not exist", errs[0].Reason.Error())
+			test.Equal(t, "Any", errs[0].Matcher)
+			test.Equal(t, "/user/email", errs[0].Path)
+		})
+
+		t.Run("should ignore missing paths", func(t *testing.T) {
+			_, errs := Any("/user/email").ErrOnMissingPath(false).XML(x)
+
+			test.Equal(t, 0, len(errs))
+		})
+
+		t.Run("should replace attributes and text", func(t *testing.T) {
+			res, errs := Any("//@id", "/user/created").Placeholder(10).XML(x)
+
+			test.Equal(t, 0, len(errs))
+			test.Equal(t, "<user id=\"10\">\n  <name>mock-user</name>\n  <created>10</created>\n</user>", string(res))
+		})
+	})
 }
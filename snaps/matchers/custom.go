@@ -2,6 +2,8 @@ package matchers
 
 import (
 	"errors"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/internal/xmltree"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -46,3 +48,34 @@ func (c *CustomMatcher) JSON(s []byte) ([]byte, []MatcherError) {
 
 	return s, nil
 }
+
+// XML calls the callback with the text of each element or the value of each attribute at the path,
+// replacing it with the returned value
+func (c *CustomMatcher) XML(s []byte) ([]byte, []MatcherError) {
+	doc, err := xmltree.Parse(s)
+	if err != nil {
+		return nil, []MatcherError{{Reason: err, Matcher: c.name, Path: c.path}}
+	}
+
+	matches, err := doc.Select(c.path)
+	if err != nil {
+		return nil, []MatcherError{{Reason: err, Matcher: c.name, Path: c.path}}
+	}
+	if len(matches) == 0 {
+		if c.errOnMissingPath {
+			return nil, []MatcherError{{Reason: errors.New("path does not exist"), Matcher: c.name, Path: c.path}}
+		}
+
+		return s, nil
+	}
+
+	for _, m := range matches {
+		value, err := c.callback(m.Value())
+		if err != nil {
+			return nil, []MatcherError{{Reason: err, Matcher: c.name, Path: c.path}}
+		}
+		m.Set(fmt.Sprint(value))
+	}
+
+	return []byte(doc.Encode()), nil
+}
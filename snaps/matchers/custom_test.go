@@ -99,4 +99,36 @@ func TestCustomMatcher(t *testing.T) {
 			test.Nil(t, errs)
 		})
 	})
+
+	t.Run("XML", func(t *testing.T) {
+		x := []byte(`<user><age>10</age></user>`)
+
+		t.Run("should return error in case of missing path", func(t *testing.T) {
+			_, errs := Custom("/user/email", func(val any) (any, error) { return val, nil }).XML(x)
+
+			test.Equal(t, 1, len(errs))
+			test.Equal(t, "path does not exist", errs[0].Reason.Error())
+		})
+
+		t.Run("should return the callback error", func(t *testing.T) {
+			_, errs := Custom("/user/age", func(val any) (any, error) {
+				return nil, errors.New("custom error")
+			}).XML(x)
+
+			test.Equal(t, 1, len(errs))
+			test.Equal(t, "custom error", errs[0].Reason.Error())
+		})
+
+		t.Run("should replace the value", func(t *testing.T) {
+			res, errs := Custom("/user/age", func(val any) (any, error) {
+				if val.(string) != "10" {
+					return nil, errors.New("unexpected age")
+				}
+				return "<adult>", nil
+			}).XML(x)
+
+			test.Equal(t, 0, len(errs))
+			test.Equal(t, "<user>\n  <age>&lt;adult&gt;</age>\n</user>", string(res))
+		})
+	})
 }
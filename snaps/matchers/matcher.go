@@ -4,6 +4,11 @@ type JsonMatcher interface {
 	JSON([]byte) ([]byte, []MatcherError)
 }
 
+// XMLMatcher is a matcher for xml documents, paths are a subset of XPath e.g. `//Assertion/@ID`
+type XMLMatcher interface {
+	XML([]byte) ([]byte, []MatcherError)
+}
+
 type MatcherError struct {
 	Reason  error
 	Matcher string
@@ -1,10 +1,13 @@
 package matchers
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/KoNekoD/go-snaps/internal/xmltree"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
+	"strconv"
 )
 
 type TypeMatcher[ExpectedType any] struct {
@@ -54,3 +57,39 @@ func (t *TypeMatcher[ExpectedType]) JSON(s []byte) ([]byte, []MatcherError) {
 
 	return json, errs
 }
+
+// XML checks the text of each element or the value of each attribute at the path can be parsed as
+// ExpectedType, as a json value or a json string e.g. `42` for int and `2024-01-01T00:00:00Z` for time.Time
+func (t *TypeMatcher[ExpectedType]) XML(s []byte) ([]byte, []MatcherError) {
+	doc, err := xmltree.Parse(s)
+	if err != nil {
+		return nil, []MatcherError{{Reason: err, Matcher: t.name}}
+	}
+
+	var errs []MatcherError
+	for _, path := range t.paths {
+		matches, err := doc.Select(path)
+		if err != nil {
+			errs = append(errs, MatcherError{Reason: err, Matcher: t.name, Path: path})
+			continue
+		}
+		if len(matches) == 0 {
+			if t.errOnMissingPath {
+				errs = append(errs, MatcherError{Reason: errors.New("path does not exist"), Matcher: t.name, Path: path})
+			}
+			continue
+		}
+
+		for _, m := range matches {
+			var v ExpectedType
+			if json.Unmarshal([]byte(m.Value()), &v) != nil && json.Unmarshal([]byte(strconv.Quote(m.Value())), &v) != nil {
+				errs = append(errs, MatcherError{Reason: fmt.Errorf("expected type %T, received %q", v, m.Value()), Matcher: t.name, Path: path})
+				continue
+			}
+
+			m.Set(fmt.Sprintf("<Type:%T>", v))
+		}
+	}
+
+	return []byte(doc.Encode()), errs
+}
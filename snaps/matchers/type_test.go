@@ -4,6 +4,7 @@ import (
 	"github.com/KoNekoD/go-snaps/internal/test"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestTypeMatcher(t *testing.T) {
@@ -87,4 +88,28 @@ func TestTypeMatcher(t *testing.T) {
 			test.Equal(t, "expected type int, received float64", errs[1].Reason.Error())
 		})
 	})
+
+	t.Run("XML", func(t *testing.T) {
+		x := []byte(`<user age="10"><name>mock-user</name><created>2024-01-01T00:00:00Z</created></user>`)
+
+		t.Run("should replace values parsed as the expected type", func(t *testing.T) {
+			res, errs := Type[float64]("/user/@age").XML(x)
+			test.Equal(t, 0, len(errs))
+
+			res, errs = Type[time.Time]("/user/created").XML(res)
+			test.Equal(t, 0, len(errs))
+			test.Equal(
+				t,
+				"<user age=\"&lt;Type:float64&gt;\">\n  <name>mock-user</name>\n  <created>&lt;Type:time.Time&gt;</created>\n</user>",
+				string(res),
+			)
+		})
+
+		t.Run("should return error on type mismatch", func(t *testing.T) {
+			_, errs := Type[int]("/user/name").XML(x)
+
+			test.Equal(t, 1, len(errs))
+			test.Equal(t, `expected type int, received "mock-user"`, errs[0].Reason.Error())
+		})
+	})
 }
@@ -0,0 +1,112 @@
+package snaps
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mirrorStore reads snapshots in place but writes the ones under `from` to the same relative path under `to`,
+// snapshots already written to `to` take precedence when reading so a run sees its own writes.
+type mirrorStore struct {
+	base Store
+	from string
+	to   string
+}
+
+func newMirrorStore(base Store, from, to string) *mirrorStore {
+	if abs, err := filepath.Abs(from); err == nil {
+		from = abs
+	}
+	if abs, err := filepath.Abs(to); err == nil {
+		to = abs
+	}
+
+	return &mirrorStore{base: base, from: from, to: to}
+}
+
+// mirror returns the path under `to` for paths under `from`
+func (m *mirrorStore) mirror(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path, false
+	}
+	rel, err := filepath.Rel(m.from, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path, false
+	}
+
+	return filepath.Join(m.to, rel), true
+}
+
+func (m *mirrorStore) Read(path string) ([]byte, error) {
+	mirrored, ok := m.mirror(path)
+	if !ok {
+		return m.base.Read(path)
+	}
+
+	data, err := m.base.Read(mirrored)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m.base.Read(path)
+	}
+
+	return data, err
+}
+
+func (m *mirrorStore) Write(path string, data []byte) error {
+	mirrored, _ := m.mirror(path)
+	return m.base.Write(mirrored, data)
+}
+
+// Delete removes the mirrored file, files read in place are never modified
+func (m *mirrorStore) Delete(path string) error {
+	mirrored, _ := m.mirror(path)
+	return m.base.Delete(mirrored)
+}
+
+func (m *mirrorStore) List(dir string) ([]string, error) {
+	return m.merge(dir, m.base.List)
+}
+
+func (m *mirrorStore) ListDirs(dir string) ([]string, error) {
+	return m.merge(dir, func(dir string) ([]string, error) { return listDirs(m.base, dir) })
+}
+
+// merge lists dir both in place and mirrored
+func (m *mirrorStore) merge(dir string, list func(string) ([]string, error)) ([]string, error) {
+	names, err := list(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if mirrored, ok := m.mirror(dir); ok {
+		written, werr := list(mirrored)
+		if werr != nil && !errors.Is(werr, fs.ErrNotExist) {
+			return nil, werr
+		}
+		if werr == nil {
+			err = nil
+		}
+		names = append(names, written...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(names)
+	unique := names[:0]
+	for i, name := range names {
+		if i == 0 || names[i-1] != name {
+			unique = append(unique, name)
+		}
+	}
+
+	return unique, nil
+}
+
+func (m *mirrorStore) Lock(path string) (func(), error) {
+	mirrored, _ := m.mirror(path)
+	return lockStore(m.base, mirrored)
+}
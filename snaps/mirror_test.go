@@ -0,0 +1,107 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMirrorStore(t *testing.T) {
+	from, to := t.TempDir(), t.TempDir()
+	store := newMirrorStore(NewDiskStore(), from, to)
+	_ = os.WriteFile(filepath.Join(from, "TestA_1.snap"), []byte("in place"), os.ModePerm)
+	_ = os.WriteFile(filepath.Join(from, "TestB_1.snap"), []byte("in place"), os.ModePerm)
+
+	t.Run("should write mirrored and read own writes first", func(t *testing.T) {
+		test.NoError(t, store.Write(filepath.Join(from, "TestB_1.snap"), []byte("written")))
+		test.NoError(t, store.Write(filepath.Join(from, "TestC_1.snap"), []byte("written")))
+
+		test.Equal(t, "in place", test.GetFileContent(t, filepath.Join(from, "TestB_1.snap")))
+		test.Equal(t, "written", test.GetFileContent(t, filepath.Join(to, "TestB_1.snap")))
+
+		data, err := store.Read(filepath.Join(from, "TestA_1.snap"))
+		test.NoError(t, err)
+		test.Equal(t, "in place", string(data))
+		data, err = store.Read(filepath.Join(from, "TestB_1.snap"))
+		test.NoError(t, err)
+		test.Equal(t, "written", string(data))
+	})
+
+	t.Run("should list files of both dirs", func(t *testing.T) {
+		names, err := store.List(from)
+
+		test.NoError(t, err)
+		test.Equal(t, []string{"TestA_1.snap", "TestB_1.snap", "TestC_1.snap"}, names)
+	})
+
+	t.Run("should only delete mirrored files", func(t *testing.T) {
+		test.NoError(t, store.Delete(filepath.Join(from, "TestC_1.snap")))
+		err := store.Delete(filepath.Join(from, "TestA_1.snap"))
+
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+		test.Equal(t, "in place", test.GetFileContent(t, filepath.Join(from, "TestA_1.snap")))
+	})
+
+	t.Run("should leave paths outside the mirrored dir in place", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "TestD_1.snap")
+
+		test.NoError(t, store.Write(path, []byte("outside")))
+		test.Equal(t, "outside", test.GetFileContent(t, path))
+	})
+}
+
+func TestOutputDir(t *testing.T) {
+	resetEnv(t)
+
+	snapsDir := filepath.Join(sourceRoot(), "snaps", "__snapshots__")
+	rel := func(name string) string { return filepath.Join("snaps", "__snapshots__", name) }
+
+	t.Run("should write mirrored under the output dir", func(t *testing.T) {
+		out := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestOutputDir")
+
+		WithConfig(OutputDir(out)).MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "hello world", test.GetFileContent(t, filepath.Join(out, rel("TestOutputDir_1.snap"))))
+		_, err := os.Stat(filepath.Join(snapsDir, "TestOutputDir_1.snap"))
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("should read in place and write pending snapshots mirrored", func(t *testing.T) {
+		out := t.TempDir()
+		outputDirVAR = out
+		path := filepath.Join(snapsDir, "TestOutputDirPending_1.snap")
+		_ = os.MkdirAll(snapsDir, os.ModePerm)
+		_ = os.WriteFile(path, []byte("hello world"), os.ModePerm)
+		t.Cleanup(func() { _ = os.Remove(path) })
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestOutputDirPending")
+
+		WithConfig(Mode(UpdatePending)).MatchSnapshot(mockT, "hello universe")
+
+		test.Equal(t, 1, len(*errs))
+		test.Equal(t, "hello world", test.GetFileContent(t, path))
+		test.Equal(t, "hello universe", test.GetFileContent(t, filepath.Join(out, rel("TestOutputDirPending_1.snap.new"))))
+	})
+	t.Run("should only report obsolete snapshots actually removed", func(t *testing.T) {
+		from, to := t.TempDir(), t.TempDir()
+		_ = os.WriteFile(filepath.Join(from, "TestInPlace_1.snap"), []byte("in place"), os.ModePerm)
+		_ = os.WriteFile(filepath.Join(to, "TestWritten_1.snap"), []byte("written"), os.ModePerm)
+		r := newSnapRegistry()
+		r.registryStores[from] = newMirrorStore(NewDiskStore(), from, to)
+		obsolete := []obsoleteSnapshot{
+			{path: filepath.Join(from, "TestInPlace_1.snap")},
+			{path: filepath.Join(from, "TestWritten_1.snap")},
+		}
+
+		removed, kept, err := r.deleteSnapshots(obsolete)
+
+		test.NoError(t, err)
+		test.Equal(t, obsolete[1:], removed)
+		test.Equal(t, obsolete[:1], kept)
+		test.Equal(t, "in place", test.GetFileContent(t, filepath.Join(from, "TestInPlace_1.snap")))
+	})
+}
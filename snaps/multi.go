@@ -0,0 +1,191 @@
+package snaps
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+)
+
+// multiSnapshotMutex serializes read-modify-write cycles of snapshot files shared by multiple tests,
+// stores implementing Locker also lock them across test binaries
+var multiSnapshotMutex sync.Mutex
+
+// snapshotSection is a named snapshot inside a FilePerTestFile snapshot file
+//
+//	[TestFoo/case - 1]
+//	snapshot content
+//	---
+type snapshotSection struct {
+	id      string
+	content string
+}
+
+const sectionEnd = "---"
+
+// parseMultiSnapshot splits a snapshot file into its sections, keeping their order
+func parseMultiSnapshot(data string) ([]snapshotSection, error) {
+	sections := make([]snapshotSection, 0)
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+			return nil, fmt.Errorf("invalid snapshot file: expected section header at line %d", i+1)
+		}
+
+		id := line[1 : len(line)-1]
+		start := i + 1
+		for i++; i < len(lines) && lines[i] != sectionEnd; i++ {
+		}
+		if i == len(lines) {
+			return nil, fmt.Errorf("invalid snapshot file: section [%s] is not terminated", id)
+		}
+
+		content := lines[start:i]
+		for j, l := range content {
+			content[j] = unescapeSectionLine(l)
+		}
+		sections = append(sections, snapshotSection{id: id, content: strings.Join(content, "\n")})
+	}
+
+	return sections, nil
+}
+
+func formatMultiSnapshot(sections []snapshotSection) string {
+	var s strings.Builder
+	for _, section := range sections {
+		lines := strings.Split(section.content, "\n")
+		for j, l := range lines {
+			lines[j] = escapeSectionLine(l)
+		}
+
+		s.WriteString("\n[" + section.id + "]\n")
+		s.WriteString(strings.Join(lines, "\n"))
+		s.WriteString("\n" + sectionEnd + "\n")
+	}
+
+	return s.String()
+}
+
+// escapeSectionLine prefixes lines that would be read as the end of a section with a backslash,
+// lines already made of backslashes followed by the terminator get one more.
+func escapeSectionLine(line string) string {
+	if strings.TrimLeft(line, `\`) == sectionEnd {
+		return `\` + line
+	}
+
+	return line
+}
+
+func unescapeSectionLine(line string) string {
+	if strings.HasPrefix(line, `\`) && strings.TrimLeft(line, `\`) == sectionEnd {
+		return line[1:]
+	}
+
+	return line
+}
+
+func readMultiSnapshot(store Store, snapPath, id string) (string, error) {
+	multiSnapshotMutex.Lock()
+	defer multiSnapshotMutex.Unlock()
+
+	sections, err := readSections(store, snapPath)
+	if err != nil {
+		return "", err
+	}
+	for _, section := range sections {
+		if section.id == id {
+			return section.content, nil
+		}
+	}
+
+	return "", errSnapNotFound
+}
+
+// upsertMultiSnapshot reads the sections of base, replaces or appends the section and writes the result to snapPath
+func upsertMultiSnapshot(store Store, snapshot, base, snapPath, id string) error {
+	multiSnapshotMutex.Lock()
+	defer multiSnapshotMutex.Unlock()
+
+	unlock, err := lockStore(store, snapPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sections, err := readSections(store, base)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return store.Write(snapPath, []byte(formatMultiSnapshot(upsertSection(sections, id, snapshot))))
+}
+
+// upsertSection replaces the content of the section or appends it
+func upsertSection(sections []snapshotSection, id, content string) []snapshotSection {
+	for i := range sections {
+		if sections[i].id == id {
+			sections[i].content = content
+			return sections
+		}
+	}
+
+	return append(sections, snapshotSection{id: id, content: content})
+}
+
+// removeSections deletes the sections from the snapshot file, removing the file if no sections are left
+func removeSections(store Store, snapPath string, ids map[string]bool) error {
+	multiSnapshotMutex.Lock()
+	defer multiSnapshotMutex.Unlock()
+
+	unlock, err := lockStore(store, snapPath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	sections, err := readSections(store, snapPath)
+	if err != nil {
+		return err
+	}
+
+	kept := make([]snapshotSection, 0, len(sections))
+	for _, section := range sections {
+		if !ids[section.id] {
+			kept = append(kept, section)
+		}
+	}
+	if len(kept) == 0 {
+		return store.Delete(snapPath)
+	}
+
+	return store.Write(snapPath, []byte(formatMultiSnapshot(kept)))
+}
+
+func readSections(store Store, snapPath string) ([]snapshotSection, error) {
+	data, err := store.Read(snapPath)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err := parseMultiSnapshot(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", snapPath, err)
+	}
+
+	return sections, nil
+}
+
+// sectionTest returns the test name of a section id e.g. `TestFoo/case` for `TestFoo/case - 2`
+func sectionTest(id string) string {
+	if i := strings.LastIndex(id, " - "); i != -1 {
+		return id[:i]
+	}
+
+	return id
+}
@@ -0,0 +1,158 @@
+package snaps
+
+import (
+	"fmt"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMultiSnapshotFormat(t *testing.T) {
+	t.Run("should parse snapshot file", func(t *testing.T) {
+		sections, err := parseMultiSnapshot(test.GetFileContent(t, "testdata/mock-snap-1"))
+
+		test.NoError(t, err)
+		test.Equal(t, 4, len(sections))
+		test.Equal(t, snapshotSection{id: "TestDir1_3/TestSimple - 1", content: "int(100)\nstring hello world 1 3 1"}, sections[0])
+		test.Equal(t, snapshotSection{id: "TestDir1_1/TestSimple - 1", content: "\nint(1)\n\nstring hello world 1 1 1\n"}, sections[3])
+		test.Equal(t, "TestDir1_1/TestSimple", sectionTest(sections[3].id))
+	})
+
+	t.Run("should format sections back to the same file", func(t *testing.T) {
+		for _, name := range []string{"testdata/mock-snap-sort-1-sorted", "testdata/mock-snap-sort-2-sorted"} {
+			content := test.GetFileContent(t, name)
+			sections, err := parseMultiSnapshot(content)
+
+			test.NoError(t, err)
+			test.Equal(t, content, formatMultiSnapshot(sections))
+		}
+	})
+
+	t.Run("should escape section terminators", func(t *testing.T) {
+		sections := []snapshotSection{{id: "TestA - 1", content: "---\n\\---\nyaml: true\n---"}}
+
+		content := formatMultiSnapshot(sections)
+		parsed, err := parseMultiSnapshot(content)
+
+		test.Equal(t, "\n[TestA - 1]\n\\---\n\\\\---\nyaml: true\n\\---\n---\n", content)
+		test.NoError(t, err)
+		test.Equal(t, sections, parsed)
+	})
+
+	t.Run("should return error for invalid files", func(t *testing.T) {
+		_, err := parseMultiSnapshot("\n[TestA - 1]\nmock")
+		test.Contains(t, fmt.Sprint(err), "is not terminated")
+
+		_, err = parseMultiSnapshot("mock\n---\n")
+		test.Contains(t, fmt.Sprint(err), "expected section header at line 1")
+	})
+}
+
+func TestMatchFilePerTestFile(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should store snapshots of all tests in one file", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir), Layout(FilePerTestFile))
+		mockA, errs := test.NewRecordingMockTestingT(t, "TestA")
+		mockA.MockCleanup = func(func()) {}
+		mockB, _ := test.NewRecordingMockTestingT(t, "TestB/case")
+
+		c.MatchSnapshot(mockA, "hello")
+		c.MatchJSON(mockB, `{"a":1}`)
+		c.MatchSnapshot(mockA, "world")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(
+			t,
+			"\n[TestA - 1]\nhello\n---\n\n[TestB/case - 1]\n{\n \"a\": 1\n}\n---\n\n[TestA - 2]\nworld\n---\n",
+			test.GetFileContent(t, filepath.Join(dir, "multi_test.snap")),
+		)
+	})
+
+	t.Run("should compare and update sections", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "multi_test.snap")
+		_ = os.WriteFile(path, []byte("\n[TestA - 1]\nhello\n---\n\n[TestB - 1]\nworld\n---\n"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestB")
+
+		WithConfig(Dir(dir), Layout(FilePerTestFile)).MatchSnapshot(mockT, "world")
+		test.Equal(t, 0, len(*errs))
+
+		WithConfig(Dir(dir), Layout(FilePerTestFile), Mode(UpdateNew)).MatchSnapshot(mockT, "universe")
+		test.Equal(t, 1, len(*errs))
+
+		WithConfig(Dir(dir), Layout(FilePerTestFile), Mode(UpdateFailing)).MatchSnapshot(mockT, "universe")
+		test.Equal(t, 1, len(*errs))
+		test.Equal(t, "\n[TestA - 1]\nhello\n---\n\n[TestB - 1]\nuniverse\n---\n", test.GetFileContent(t, path))
+	})
+
+	t.Run("should write the whole file for pending review", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "multi_test.snap")
+		_ = os.WriteFile(path, []byte("\n[TestA - 1]\nhello\n---\n"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestB")
+
+		WithConfig(Dir(dir), Layout(FilePerTestFile), Mode(UpdatePending)).MatchSnapshot(mockT, "world")
+
+		test.Equal(t, 1, len(*errs))
+		test.Equal(t, "\n[TestA - 1]\nhello\n---\n", test.GetFileContent(t, path))
+		test.Equal(t, "\n[TestA - 1]\nhello\n---\n\n[TestB - 1]\nworld\n---\n", test.GetFileContent(t, path+pendingExtension))
+	})
+
+	t.Run("should not overwrite invalid files", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "multi_test.snap")
+		_ = os.WriteFile(path, []byte("invalid"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestA")
+
+		WithConfig(Dir(dir), Layout(FilePerTestFile)).MatchSnapshot(mockT, "hello")
+
+		test.Equal(t, 1, len(*errs))
+		test.Equal(t, "invalid", test.GetFileContent(t, path))
+	})
+}
+
+func TestObsoleteSections(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mock_test.snap")
+	_ = os.WriteFile(path, []byte(test.GetFileContent(t, "testdata/mock-snap-sort-1-sorted")), os.ModePerm)
+	r := newSnapRegistry()
+	r.registrySections[path] = map[string]int{"TestAlpha - 1": 1, "TestBeta - 1": 1}
+	r.registryTests["TestAlpha"] = 1
+	r.skippedTests = append(r.skippedTests, "TestDir1_3")
+
+	t.Run("should report sections no test produced", func(t *testing.T) {
+		obsolete, err := r.obsoleteSections(false)
+
+		test.NoError(t, err)
+		test.Equal(t, []obsoleteSnapshot{
+			{path: path, section: "TestAlpha - 2"},
+			{path: path, section: "TestCat - 1"},
+			{path: path, section: "TestDir1_1/TestSimple - 1"},
+			{path: path, section: "TestDir1_2/TestSimple - 1"},
+		}, obsolete)
+	})
+
+	t.Run("should only consider tests that ran on filtered runs", func(t *testing.T) {
+		obsolete, err := r.obsoleteSections(true)
+
+		test.NoError(t, err)
+		test.Equal(t, []obsoleteSnapshot{{path: path, section: "TestAlpha - 2"}}, obsolete)
+	})
+
+	t.Run("should remove obsolete sections", func(t *testing.T) {
+		obsolete, _ := r.obsoleteSections(false)
+
+		removed, kept, err := r.deleteSnapshots(obsolete)
+
+		test.NoError(t, err)
+		test.Equal(t, obsolete, removed)
+		test.Equal(t, 0, len(kept))
+
+		test.Equal(t, "\n[TestAlpha - 1]\nmock snapshot\n---\n\n[TestBeta - 1]\nmock snapshot\nand\n\nanother \n\nvalue\n\n---\n\n"+
+			"[TestDir1_3/TestSimple - 1]\nint(100)\nstring hello world 1 3 1\n---\n\n"+
+			"[TestDir1_3/TestSimple - 2]\nint(1000)\nstring hello world 1 3 2\n---\n", test.GetFileContent(t, path))
+	})
+}
@@ -0,0 +1,55 @@
+package snaps
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	errEmptyName     = errors.New("snapshot name can't be empty")
+	errDuplicateName = errors.New("snapshot name already used in this test")
+	errNumericName   = errors.New("snapshot name can't be a number, it collides with numbered snapshots")
+)
+
+// withName marks the snapshot as named, named snapshots are stored under `<TestName>_<name>`
+// instead of a call counter, so adding assertions to a test doesn't rename the rest
+func (s *snap) withName(name string) *snap {
+	s.name, s.named = name, true
+
+	return s
+}
+
+// registerName reserves the snapshot name for the running test, failing when the test already used it or
+// a name stored under the same file name e.g. `a/b` and `a_b`.
+// Names are released once the test finishes so `-count` runs can take them again.
+func (s *snap) registerName() error {
+	if s.name == "" {
+		return errEmptyName
+	}
+	if strings.Trim(s.name, "0123456789") == "" {
+		return fmt.Errorf("%w: %q", errNumericName, s.name)
+	}
+	key := testFilename(s.t.Name()) + "_" + testFilename(s.name)
+
+	s.registry.registryMutex.Lock()
+	taken, ok := s.registry.registryNames[key]
+	if !ok {
+		s.registry.registryNames[key] = s.name
+	}
+	s.registry.registryMutex.Unlock()
+	switch {
+	case ok && taken == s.name:
+		return fmt.Errorf("%w: %q", errDuplicateName, s.name)
+	case ok:
+		return fmt.Errorf("%w: %q is stored under %q like %q", errDuplicateName, s.name, key, taken)
+	}
+
+	s.t.Cleanup(func() {
+		s.registry.registryMutex.Lock()
+		delete(s.registry.registryNames, key)
+		s.registry.registryMutex.Unlock()
+	})
+
+	return nil
+}
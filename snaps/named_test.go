@@ -0,0 +1,119 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchNamed(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should store snapshots under their name", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir))
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestNamed/case")
+
+		c.MatchNamed(mockT, "total", 10, "eur")
+		c.MatchNamedJSON(mockT, "order", `{"id":1}`)
+		c.MatchNamedStandalone(mockT, "status", "paid")
+		c.MatchSnapshot(mockT, "numbered")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "int(10)\neur", test.GetFileContent(t, filepath.Join(dir, "TestNamed_case_total.snap")))
+		test.Equal(t, "{\n \"id\": 1\n}", test.GetFileContent(t, filepath.Join(dir, "TestNamed_case_order.json")))
+		test.Equal(t, "paid", test.GetFileContent(t, filepath.Join(dir, "TestNamed_case_status.snap")))
+		test.Equal(t, "numbered", test.GetFileContent(t, filepath.Join(dir, "TestNamed_case_1.snap")))
+	})
+
+	t.Run("should not be affected by numbered snapshots", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir))
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestNamed/inserted")
+		mockT.MockCleanup = t.Cleanup
+
+		c.MatchNamed(mockT, "total", 10)
+		c.MatchSnapshot(mockT, "inserted")
+		c.MatchNamed(mockT, "status", "paid")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "paid", test.GetFileContent(t, filepath.Join(dir, "TestNamed_inserted_status.snap")))
+	})
+
+	t.Run("should fail on duplicate names within a test", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir))
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestNamed/duplicate")
+		cleanups := make([]func(), 0)
+		mockT.MockCleanup = func(f func()) { cleanups = append(cleanups, f) }
+		t.Cleanup(func() {
+			for _, f := range cleanups {
+				f()
+			}
+		})
+
+		c.MatchNamed(mockT, "total", 10)
+		c.MatchNamedJSON(mockT, "total", `{"total":10}`)
+
+		test.Equal(t, 1, len(*errs))
+		test.True(t, errors.Is((*errs)[0].(error), errDuplicateName))
+
+		for _, f := range cleanups {
+			f()
+		}
+		c.MatchNamed(mockT, "total", 10)
+		test.Equal(t, 1, len(*errs))
+	})
+
+	t.Run("should fail on names stored under the same file name", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir))
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestNamed/collision")
+		mockT.MockCleanup = t.Cleanup
+
+		c.MatchNamed(mockT, "a/b", 1)
+		c.MatchNamed(mockT, "a_b", 2)
+
+		test.Equal(t, 1, len(*errs))
+		test.True(t, errors.Is((*errs)[0].(error), errDuplicateName))
+		test.Contains(t, (*errs)[0].(error).Error(), `"a_b" is stored under "TestNamed_collision_a_b" like "a/b"`)
+		test.Equal(t, "int(1)", test.GetFileContent(t, filepath.Join(dir, "TestNamed_collision_a_b.snap")))
+	})
+
+	t.Run("should fail on empty names", func(t *testing.T) {
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestNamed/empty")
+
+		WithConfig(Dir(t.TempDir())).MatchNamed(mockT, "", 10)
+
+		test.Equal(t, 1, len(*errs))
+		test.Equal[any](t, errEmptyName, (*errs)[0])
+	})
+
+	t.Run("should fail on numeric names", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestNamed/numeric")
+
+		WithConfig(Dir(dir)).MatchNamed(mockT, "1", 10)
+
+		test.Equal(t, 1, len(*errs))
+		test.True(t, errors.Is((*errs)[0].(error), errNumericName))
+		_, err := os.Stat(filepath.Join(dir, "TestNamed_numeric_1.snap"))
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("should store named sections with the FilePerTestFile layout", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir), Layout(FilePerTestFile))
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestNamed/layout")
+
+		c.MatchNamed(mockT, "total", 10)
+
+		test.Equal(t, 0, len(*errs))
+		snapshot, err := readMultiSnapshot(NewDiskStore(), filepath.Join(dir, "named_test.snap"), "TestNamed/layout - total")
+		test.NoError(t, err)
+		test.Equal(t, "int(10)", snapshot)
+	})
+}
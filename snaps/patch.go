@@ -0,0 +1,233 @@
+package snaps
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/snaps/diff"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const patchFileHeader = "diff --git a/"
+
+// patchEntry is the change a failing run would have made to a snapshot file or to the test source
+// of inline snapshots
+type patchEntry struct {
+	exists bool
+	old    string
+	new    string
+	shifts []inlineShift // lines added or removed by inline snapshots rewritten in new
+}
+
+// registerPatch records the snapshot that wasn't written, so Clean can write it to the patch
+func (s *snap) registerPatch(snapshot, snapPath, section string) {
+	s.registry.registryPatchesMutex.Lock()
+	defer s.registry.registryPatchesMutex.Unlock()
+
+	entry, ok := s.registry.registryPatches[snapPath]
+	if !ok {
+		data, err := s.c.Store().Read(snapPath)
+		entry = &patchEntry{exists: err == nil, old: string(data), new: string(data)}
+		s.registry.registryPatches[snapPath] = entry
+	}
+
+	if section == "" {
+		entry.new = s.withHeader(snapshot)
+		return
+	}
+	sections, err := parseMultiSnapshot(entry.new)
+	if err != nil {
+		sections = nil
+	}
+	entry.new = formatMultiSnapshot(upsertSection(sections, section, s.withHeader(snapshot)))
+}
+
+// registerInlinePatch records the inline snapshot that wasn't written, so Clean can write the rewritten
+// test source to the patch
+func (s *snap) registerInlinePatch(file string, line int, snapshot string) {
+	s.registry.registryPatchesMutex.Lock()
+	defer s.registry.registryPatchesMutex.Unlock()
+
+	entry, ok := s.registry.registryPatches[file]
+	if !ok {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return
+		}
+		entry = &patchEntry{exists: true, old: string(data), new: string(data)}
+		s.registry.registryPatches[file] = entry
+	}
+
+	out, err := rewriteInlineSnapshot([]byte(entry.new), shiftedLine(entry.shifts, line), snapshot)
+	if err != nil {
+		return
+	}
+	delta := strings.Count(string(out), "\n") - strings.Count(entry.new, "\n")
+	entry.shifts = append(entry.shifts, inlineShift{line: line, delta: delta})
+	entry.new = string(out)
+}
+
+// registerInlineSource records the test source an inline snapshot was matched in, so Clean replaces
+// its changes in the patch even once its inline snapshots pass
+func (s *snap) registerInlineSource(file string) {
+	s.registry.registryPatchesMutex.Lock()
+	defer s.registry.registryPatchesMutex.Unlock()
+	s.registry.registrySources[file]++
+}
+
+// writePatch merges the snapshots this run failed to write into the patch file at path.
+//
+// Test binaries of every package write to the same patch, so only the files of snapshots and the
+// test sources of inline snapshots used in this run are replaced. The patch is removed once it has no changes left.
+func (r *snapRegistry) writePatch(path, root string) (int, error) {
+	used := make(map[string]bool)
+	r.registryPatchesMutex.Lock()
+	patches := make(map[string]string, len(r.registryPatches))
+	for snapPath, entry := range r.registryPatches {
+		if entry.exists && entry.old == entry.new {
+			continue
+		}
+		rel := patchPath(root, snapPath)
+		patches[rel] = formatFilePatch(rel, entry)
+	}
+	for file := range r.registrySources {
+		used[patchPath(root, file)] = true
+	}
+	r.registryPatchesMutex.Unlock()
+	written := len(patches)
+
+	r.registryMutex.Lock()
+	for snapPath := range r.registryCleanup {
+		used[patchPath(root, snapPath)] = true
+	}
+	r.registryMutex.Unlock()
+
+	store := NewDiskStore()
+	unlock, err := lockStore(store, path)
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return 0, err
+	}
+	for rel, block := range splitPatch(string(existing)) {
+		if _, ok := patches[rel]; !ok && !used[rel] {
+			patches[rel] = block
+		}
+	}
+
+	if len(patches) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return 0, err
+		}
+		return 0, nil
+	}
+
+	paths := make([]string, 0, len(patches))
+	for rel := range patches {
+		paths = append(paths, rel)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	for _, rel := range paths {
+		b.WriteString(patches[rel])
+	}
+
+	return written, store.Write(path, []byte(b.String()))
+}
+
+// patchPath returns the path of the snapshot in the patch, relative to the module root
+func patchPath(root, snapPath string) string {
+	if rel, err := filepath.Rel(root, snapPath); err == nil {
+		return filepath.ToSlash(rel)
+	}
+
+	return filepath.ToSlash(snapPath)
+}
+
+// splitPatch splits a patch into the patches of each file, keyed by path
+func splitPatch(patch string) map[string]string {
+	var (
+		blocks = make(map[string]string)
+		block  strings.Builder
+		path   string
+	)
+	for _, line := range patchLines(patch) {
+		if strings.HasPrefix(line, patchFileHeader) {
+			if path != "" {
+				blocks[path] = block.String()
+			}
+			block.Reset()
+
+			// the header is `diff --git a/<path> b/<path>`
+			header := strings.TrimSuffix(strings.TrimPrefix(line, patchFileHeader), "\n")
+			path = header[:max(len(header)-3, 0)/2]
+		}
+		block.WriteString(line)
+	}
+	if path != "" {
+		blocks[path] = block.String()
+	}
+
+	return blocks
+}
+
+// formatFilePatch renders the change as a git diff with 3 lines of context
+func formatFilePatch(path string, entry *patchEntry) string {
+	var b strings.Builder
+	b.WriteString(patchFileHeader + path + " b/" + path + "\n")
+	if entry.exists {
+		b.WriteString("--- a/" + path + "\n")
+	} else {
+		b.WriteString("new file mode 100644\n--- /dev/null\n")
+	}
+	b.WriteString("+++ b/" + path + "\n")
+
+	a, c := patchLines(entry.old), patchLines(entry.new)
+	for _, group := range diff.NewMatcher(a, c).GetGroupedOpCodes(3) {
+		first, last := group[0], group[len(group)-1]
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n",
+			diff.FormatRangeUnified(first.I1, last.I2), diff.FormatRangeUnified(first.J1, last.J2))
+
+		for _, op := range group {
+			if op.Tag == diff.OpEqual {
+				writePatchLines(&b, " ", a[op.I1:op.I2])
+				continue
+			}
+			if op.Tag == diff.OpDelete || op.Tag == diff.OpReplace {
+				writePatchLines(&b, "-", a[op.I1:op.I2])
+			}
+			if op.Tag == diff.OpInsert || op.Tag == diff.OpReplace {
+				writePatchLines(&b, "+", c[op.J1:op.J2])
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// patchLines splits content keeping line endings, so a missing newline at the end of file is a change
+func patchLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+func writePatchLines(b *strings.Builder, prefix string, lines []string) {
+	for _, line := range lines {
+		b.WriteString(prefix + line)
+		if !strings.HasSuffix(line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatFilePatch(t *testing.T) {
+	t.Run("should format changes of existing files", func(t *testing.T) {
+		entry := &patchEntry{exists: true, old: "a\nb\nc\n", new: "a\nB\nc\n"}
+
+		test.Equal(t, `diff --git a/pkg/__snapshots__/TestA_1.snap b/pkg/__snapshots__/TestA_1.snap
+--- a/pkg/__snapshots__/TestA_1.snap
++++ b/pkg/__snapshots__/TestA_1.snap
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`, formatFilePatch("pkg/__snapshots__/TestA_1.snap", entry))
+	})
+
+	t.Run("should format new files", func(t *testing.T) {
+		entry := &patchEntry{new: "hello world"}
+
+		test.Equal(t, `diff --git a/TestA_1.snap b/TestA_1.snap
+new file mode 100644
+--- /dev/null
++++ b/TestA_1.snap
+@@ -0,0 +1 @@
++hello world
+\ No newline at end of file
+`, formatFilePatch("TestA_1.snap", entry))
+	})
+}
+
+func TestSplitPatch(t *testing.T) {
+	a := formatFilePatch("TestA_1.snap", &patchEntry{new: "a\n"})
+	b := formatFilePatch("pkg/TestB_1.snap", &patchEntry{exists: true, old: "b", new: "B"})
+
+	test.Equal(t, map[string]string{"TestA_1.snap": a, "pkg/TestB_1.snap": b}, splitPatch(a+b))
+	test.Equal(t, 0, len(splitPatch("")))
+}
+
+func TestWritePatch(t *testing.T) {
+	t.Run("should merge with the patch of other test binaries", func(t *testing.T) {
+		root := t.TempDir()
+		path := filepath.Join(t.TempDir(), "snaps.patch")
+		other := formatFilePatch("other/TestB_1.snap", &patchEntry{new: "b\n"})
+		stale := formatFilePatch("TestA_1.snap", &patchEntry{new: "stale\n"})
+		_ = os.WriteFile(path, []byte(other+stale), os.ModePerm)
+
+		r := newSnapRegistry()
+		r.registryCleanup[filepath.Join(root, "TestA_1.snap")]++
+		r.registryPatches[filepath.Join(root, "TestA_1.snap")] = &patchEntry{new: "a\n"}
+		n, err := r.writePatch(path, root)
+
+		test.NoError(t, err)
+		test.Equal(t, 1, n)
+		test.Equal(t, formatFilePatch("TestA_1.snap", &patchEntry{new: "a\n"})+other, test.GetFileContent(t, path))
+	})
+
+	t.Run("should remove the patch once snapshots pass", func(t *testing.T) {
+		root := t.TempDir()
+		path := filepath.Join(t.TempDir(), "snaps.patch")
+		_ = os.WriteFile(path, []byte(formatFilePatch("TestA_1.snap", &patchEntry{new: "a\n"})), os.ModePerm)
+
+		r := newSnapRegistry()
+		r.registryCleanup[filepath.Join(root, "TestA_1.snap")]++
+		n, err := r.writePatch(path, root)
+
+		test.NoError(t, err)
+		test.Equal(t, 0, n)
+		_, err = os.Stat(path)
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("should remove changes of test sources once inline snapshots pass", func(t *testing.T) {
+		root := t.TempDir()
+		path := filepath.Join(t.TempDir(), "snaps.patch")
+		_ = os.WriteFile(path, []byte(formatFilePatch("a_test.go", &patchEntry{exists: true, old: "a", new: "A"})), os.ModePerm)
+
+		r := newSnapRegistry()
+		r.registrySources[filepath.Join(root, "a_test.go")]++
+		n, err := r.writePatch(path, root)
+
+		test.NoError(t, err)
+		test.Equal(t, 0, n)
+		_, err = os.Stat(path)
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+}
+
+func TestPatch(t *testing.T) {
+	resetEnv(t)
+	isCI = true
+
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git not found")
+	}
+
+	root := t.TempDir()
+	snapPath := filepath.Join(root, "__snapshots__", "patch_test.snap")
+	_ = os.MkdirAll(filepath.Dir(snapPath), os.ModePerm)
+	_ = os.WriteFile(
+		snapPath,
+		[]byte("\n[TestPatch - 1]\nint(1)\n---\n\n[TestPatch - 2]\nint(2)\n---\n"),
+		os.ModePerm,
+	)
+
+	mockT, errs := test.NewRecordingMockTestingT(t, "TestPatch")
+	mockT.MockCleanup = t.Cleanup
+
+	s := WithConfig(Dir(filepath.Dir(snapPath)), Layout(FilePerTestFile), Mode(UpdateNone))
+	s.MatchSnapshot(mockT, 1)
+	s.MatchSnapshot(mockT, 20)
+	s.MatchSnapshot(mockT, 3)
+	test.Equal(t, 2, len(*errs))
+
+	r := newSnapRegistry()
+	r.registryPatches[snapPath] = defaultRegistry.registryPatches[snapPath]
+	path := filepath.Join(t.TempDir(), "snaps.patch")
+	n, err := r.writePatch(path, root)
+	test.NoError(t, err)
+	test.Equal(t, 1, n)
+
+	for _, args := range [][]string{{"init", "-q"}, {"apply", path}} {
+		cmd := exec.Command(git, args...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		test.NoError(t, err)
+		test.Equal(t, "", string(out))
+	}
+	test.Equal(
+		t,
+		"\n[TestPatch - 1]\nint(1)\n---\n\n[TestPatch - 2]\nint(20)\n---\n\n[TestPatch - 3]\nint(3)\n---\n",
+		test.GetFileContent(t, snapPath),
+	)
+}
+
+func TestInlinePatch(t *testing.T) {
+	git, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git not found")
+	}
+
+	root := t.TempDir()
+	file := filepath.Join(root, "mock_test.go")
+	_ = os.WriteFile(file, []byte(mockInlineSource), os.ModePerm)
+
+	s := newSnap(defaultConfig(), test.NewMockTestingT(t))
+	s.registry = newSnapRegistry()
+	s.registerInlinePatch(file, 10, "hello\nhello")
+	s.registerInlinePatch(file, 13, "multiline\nmultiline")
+	test.Equal(t, mockInlineSource, test.GetFileContent(t, file))
+
+	path := filepath.Join(t.TempDir(), "snaps.patch")
+	n, err := s.registry.writePatch(path, root)
+	test.NoError(t, err)
+	test.Equal(t, 1, n)
+
+	for _, args := range [][]string{{"init", "-q"}, {"apply", path}} {
+		cmd := exec.Command(git, args...)
+		cmd.Dir = root
+		out, err := cmd.CombinedOutput()
+		test.NoError(t, err)
+		test.Equal(t, "", string(out))
+	}
+	content := test.GetFileContent(t, file)
+	test.Contains(t, content, "snaps.MatchInline(t, \"hello\", snaps.Inline(`hello\nhello`))")
+	test.Contains(t, content, "snaps.MatchInline(t, \"world\", snaps.Inline(\"world\"))")
+	test.Contains(t, content, "snaps.Inline(`multiline\nmultiline`),")
+}
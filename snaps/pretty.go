@@ -0,0 +1,313 @@
+package snaps
+
+import (
+	"cmp"
+	"fmt"
+	valuePretty "github.com/kr/pretty"
+	"github.com/kr/text"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unsafe"
+)
+
+// maxPrettyDepth is the depth kr/pretty stops printing at
+const maxPrettyDepth = 10
+
+// prettyPrinter renders values the way kr/pretty does, except values with a registered Serializer
+// which are rendered with it wherever they are e.g. struct fields, slice elements or map values.
+// Parts of the value without such values are rendered by kr/pretty itself.
+type prettyPrinter struct {
+	io.Writer
+	tw      *tabwriter.Writer
+	c       *Config
+	visited map[prettyVisit]int
+	depth   int
+	err     *error
+}
+
+type prettyVisit struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+// prettySprint renders object with kr/pretty applying the serializers registered for nested values
+func prettySprint(c *Config, object any) (string, error) {
+	v := reflect.ValueOf(object)
+	if len(c.serializers) == 0 || !hasSerializer(c, v, 0) {
+		return valuePretty.Sprint(object), nil
+	}
+
+	var (
+		b   strings.Builder
+		err error
+	)
+	tw := tabwriter.NewWriter(&b, 4, 4, 1, ' ', 0)
+	p := &prettyPrinter{Writer: tw, tw: tw, c: c, visited: make(map[prettyVisit]int), err: &err}
+	p.print(addressable(v), true)
+	_ = tw.Flush()
+
+	return b.String(), err
+}
+
+// hasSerializer reports whether a serializer is registered for v or a value nested in it
+func hasSerializer(c *Config, v reflect.Value, depth int) bool {
+	if !v.IsValid() || depth > maxPrettyDepth {
+		return false
+	}
+	v = accessible(v)
+	if _, ok := c.Serializer(v.Interface()); ok {
+		return true
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		return !v.IsNil() && hasSerializer(c, v.Elem(), depth+1)
+	case reflect.Struct:
+		v = addressable(v)
+		for i := 0; i < v.NumField(); i++ {
+			if hasSerializer(c, v.Field(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Array, reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if hasSerializer(c, v.Index(i), depth+1) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if hasSerializer(c, iter.Key(), depth+1) || hasSerializer(c, iter.Value(), depth+1) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// accessible returns v, or for values reached through unexported fields the same value usable with
+// Interface, so serializers also apply to them.
+//
+// reflect refuses Interface on such values to prevent modifying them through reflect, it doesn't protect
+// the memory itself. Using unsafe here is sound: the pointer comes from UnsafeAddr of an addressable value
+// of the same type, so it is valid and well typed, and it stays alive as it's reachable from the value being
+// printed. The value is only read, Interface copies it, as kr/pretty does when printing unexported fields.
+func accessible(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// addressable returns v or an addressable copy of it, so its unexported fields can be made accessible
+func addressable(v reflect.Value) reflect.Value {
+	if !v.IsValid() || v.CanAddr() || !v.CanInterface() {
+		return v
+	}
+	c := reflect.New(v.Type()).Elem()
+	c.Set(v)
+
+	return c
+}
+
+func (p *prettyPrinter) indent() *prettyPrinter {
+	q := *p
+	q.tw = tabwriter.NewWriter(p.Writer, 4, 4, 1, ' ', 0)
+	q.Writer = text.NewIndentWriter(q.tw, []byte{'\t'})
+	return &q
+}
+
+func (p *prettyPrinter) print(v reflect.Value, showType bool) {
+	if p.depth > maxPrettyDepth {
+		_, _ = io.WriteString(p, "!%v(DEPTH EXCEEDED)")
+		return
+	}
+	if !v.IsValid() {
+		_, _ = io.WriteString(p, "nil")
+		return
+	}
+	v = accessible(v)
+	if s, ok := p.c.Serializer(v.Interface()); ok {
+		out, err := s.Serialize(v.Interface())
+		if err != nil && *p.err == nil {
+			*p.err = err
+		}
+		_, _ = io.WriteString(p, out)
+		return
+	}
+	// kr/pretty leaves out the fields of zero structs and the entries of empty maps
+	if !hasSerializer(p.c, v, 0) || v.Kind() == reflect.Struct && v.IsZero() || v.Kind() == reflect.Map && v.Len() == 0 {
+		_, _ = io.WriteString(p, prettyLeaf(v, showType))
+		return
+	}
+
+	t := v.Type()
+	switch v.Kind() {
+	case reflect.Interface:
+		pp := *p
+		pp.depth++
+		pp.print(v.Elem(), showType)
+	case reflect.Pointer:
+		pp := *p
+		pp.depth++
+		_, _ = io.WriteString(pp, "&")
+		pp.print(v.Elem(), true)
+	case reflect.Struct:
+		v = addressable(v)
+		if v.CanAddr() {
+			visit := prettyVisit{v.UnsafeAddr(), t}
+			if depth, ok := p.visited[visit]; ok && depth < p.depth {
+				_, _ = io.WriteString(p, t.String()+"{(CYCLIC REFERENCE)}")
+				return
+			}
+			p.visited[visit] = p.depth
+		}
+
+		p.printComposite(t, showType, v.NumField(), func(pp *prettyPrinter, i int, expand bool) {
+			f := t.Field(i)
+			_, _ = io.WriteString(pp, f.Name+":")
+			if expand {
+				_, _ = io.WriteString(pp, "\t")
+			}
+			field := accessible(v.Field(i))
+			if field.Kind() == reflect.Interface && !field.IsNil() {
+				field = field.Elem()
+			}
+			pp.print(field, f.Type.Kind() == reflect.Interface || f.Type.Kind() == reflect.Struct)
+		})
+	case reflect.Array, reflect.Slice:
+		p.printComposite(t, showType, v.Len(), func(pp *prettyPrinter, i int, _ bool) {
+			pp.print(v.Index(i), t.Elem().Kind() == reflect.Interface)
+		})
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, compareMapKeys)
+		p.printComposite(t, showType, len(keys), func(pp *prettyPrinter, i int, expand bool) {
+			pp.print(keys[i], false)
+			_, _ = io.WriteString(pp, ":")
+			if expand {
+				_, _ = io.WriteString(pp, "\t")
+			}
+			pp.print(v.MapIndex(keys[i]), t.Elem().Kind() == reflect.Interface)
+		})
+	default:
+		_, _ = io.WriteString(p, prettyLeaf(v, showType))
+	}
+}
+
+// printComposite writes the n elements of a struct, array, slice or map inline or one per line
+// like kr/pretty, printElem writes the element i
+func (p *prettyPrinter) printComposite(t reflect.Type, showType bool, n int, printElem func(*prettyPrinter, int, bool)) {
+	if showType {
+		_, _ = io.WriteString(p, t.String())
+	}
+	_, _ = io.WriteString(p, "{")
+
+	expand := !canInline(t)
+	pp := p
+	if expand {
+		_, _ = io.WriteString(p, "\n")
+		pp = p.indent()
+	}
+	for i := 0; i < n; i++ {
+		printElem(pp, i, expand)
+		if expand {
+			_, _ = io.WriteString(pp, ",\n")
+		} else if i < n-1 {
+			_, _ = io.WriteString(pp, ", ")
+		}
+	}
+	if expand {
+		_ = pp.tw.Flush()
+	}
+
+	_, _ = io.WriteString(p, "}")
+}
+
+// prettyLeaf renders a value without serializers with kr/pretty, which always prints the type of
+// the top level value, the type is removed where kr/pretty leaves it out of nested values
+func prettyLeaf(v reflect.Value, showType bool) string {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "nil"
+		}
+		v = accessible(v.Elem())
+	}
+
+	x := v.Interface()
+	s := valuePretty.Sprint(x)
+	if _, ok := x.(fmt.GoStringer); ok {
+		return s
+	}
+	if v.Kind() == reflect.String {
+		// kr/pretty only quotes nested strings
+		return strconv.Quote(v.String())
+	}
+	if showType {
+		return s
+	}
+
+	rest, ok := strings.CutPrefix(s, v.Type().String())
+	switch {
+	case !ok:
+		return s
+	case rest == "(nil)":
+		return "nil"
+	case strings.HasPrefix(rest, "(") && !canExpand(v.Type()):
+		return strings.TrimSuffix(strings.TrimPrefix(rest, "("), ")")
+	}
+
+	return rest
+}
+
+func canInline(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Map:
+		return !canExpand(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			if canExpand(t.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	case reflect.Array, reflect.Slice:
+		return !canExpand(t.Elem())
+	case reflect.Interface, reflect.Pointer, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		return false
+	}
+
+	return true
+}
+
+func canExpand(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Interface, reflect.Array, reflect.Slice, reflect.Pointer:
+		return true
+	}
+
+	return false
+}
+
+// compareMapKeys orders map keys of basic kinds by value and any other key by its kr/pretty output
+func compareMapKeys(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	}
+
+	return strings.Compare(prettyLeaf(accessible(a), true), prettyLeaf(accessible(b), true))
+}
@@ -0,0 +1,133 @@
+package snaps
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// PendingSnapshot is a snapshot written with `UpdatePending` waiting to be accepted or rejected
+type PendingSnapshot struct {
+	// Path of the accepted snapshot e.g. __snapshots__/TestFoo_1.snap, it might not exist yet
+	Path string
+	// PendingPath of the received snapshot e.g. __snapshots__/TestFoo_1.snap.new
+	PendingPath string
+}
+
+// snapshotExtensions are the extensions of snapshot files, optionally followed by the one set with `Ext`
+var snapshotExtensions = []string{".snap", ".json", ".yaml", ".xml", ".html"}
+
+// FindPendingSnapshots walks root and returns every pending snapshot sorted by path.
+//
+// Only snapshot files e.g. `TestFoo_1.snap.new` inside snapshot dirs, dirs named as the base of
+// snapsDir (default: __snapshots__), are pending snapshots. Hidden, vendor and node_modules
+// directories are not visited.
+// If filter is not nil only snapshots whose path relative to root matches it are returned.
+func FindPendingSnapshots(root, snapsDir string, filter *regexp.Regexp) ([]PendingSnapshot, error) {
+	pending := make([]PendingSnapshot, 0)
+	if snapsDir == "" {
+		snapsDir = defaultConfig().SnapsDir()
+	}
+	snapsDir = filepath.Base(snapsDir)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isPendingSnapshot(d.Name()) || !inSnapsDir(root, path, snapsDir) {
+			return nil
+		}
+		if filter != nil {
+			rel, _ := filepath.Rel(root, path)
+			if !filter.MatchString(filepath.ToSlash(rel)) {
+				return nil
+			}
+		}
+
+		pending = append(pending, PendingSnapshot{Path: strings.TrimSuffix(path, pendingExtension), PendingPath: path})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Path < pending[j].Path })
+
+	return pending, nil
+}
+
+// isPendingSnapshot reports whether the file name is a snapshot file followed by the pending extension
+func isPendingSnapshot(name string) bool {
+	name, ok := strings.CutSuffix(name, pendingExtension)
+	if !ok {
+		return false
+	}
+	for _, ext := range snapshotExtensions {
+		if strings.HasSuffix(name, ext) || strings.Contains(name, ext+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inSnapsDir reports whether one of the dirs between root and path is a snapshot dir,
+// snapshots might be nested inside it with `FilenameTemplate`
+func inSnapsDir(root, path, snapsDir string) bool {
+	rel, err := filepath.Rel(root, filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+
+	return slices.Contains(strings.Split(filepath.ToSlash(rel), "/"), snapsDir)
+}
+
+// IsNew reports whether there is no accepted snapshot yet
+func (p PendingSnapshot) IsNew() bool {
+	_, err := os.Stat(p.Path)
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// Diff renders the changes between the accepted and the pending snapshot
+// the same way failing tests report them.
+//
+// name is printed at the footer of the report, usually the snapshot path.
+func (p PendingSnapshot) Diff(name string) (string, error) {
+	received, err := os.ReadFile(p.PendingPath)
+	if err != nil {
+		return "", err
+	}
+	expected, err := os.ReadFile(p.Path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	// headers change with the library version, only the snapshots are compared
+	_, expectedSnapshot, _ := ParseHeader(string(expected))
+	_, receivedSnapshot, _ := ParseHeader(string(received))
+	if expectedSnapshot == receivedSnapshot {
+		return "", nil
+	}
+
+	return buildPrettyDiff(expectedSnapshot, receivedSnapshot, name, 1), nil
+}
+
+// Accept replaces the accepted snapshot with the pending one
+func (p PendingSnapshot) Accept() error {
+	return os.Rename(p.PendingPath, p.Path)
+}
+
+// Reject removes the pending snapshot keeping the accepted one untouched
+func (p PendingSnapshot) Reject() error {
+	return os.Remove(p.PendingPath)
+}
@@ -0,0 +1,130 @@
+package snaps
+
+import (
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"github.com/KoNekoD/go-snaps/snaps/colors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestPendingSnapshots(t *testing.T) {
+	t.Cleanup(func() {
+		colors.NOCOLOR = false
+	})
+	colors.NOCOLOR = true
+
+	setup := func(t *testing.T) string {
+		t.Helper()
+		dir := setupSnapsDir(
+			t,
+			"pkg/__snapshots__/TestA_1.snap",
+			".git/TestA_1.snap.new",
+			"pkg/config.yaml.new",
+			"pkg/TestC_1.snap.new",
+			"pkg/__snapshots__/notes.txt.new",
+		)
+		_ = os.WriteFile(filepath.Join(dir, "pkg/__snapshots__/TestA_1.snap.new"), []byte("new value"), os.ModePerm)
+		_ = os.WriteFile(filepath.Join(dir, "pkg/__snapshots__/TestB_1.snap.new"), []byte("value"), os.ModePerm)
+
+		return dir
+	}
+
+	t.Run("should find pending snapshots", func(t *testing.T) {
+		dir := setup(t)
+
+		pending, err := FindPendingSnapshots(dir, "", nil)
+
+		test.NoError(t, err)
+		test.Equal(t, []PendingSnapshot{
+			{
+				Path:        filepath.Join(dir, "pkg/__snapshots__/TestA_1.snap"),
+				PendingPath: filepath.Join(dir, "pkg/__snapshots__/TestA_1.snap.new"),
+			},
+			{
+				Path:        filepath.Join(dir, "pkg/__snapshots__/TestB_1.snap"),
+				PendingPath: filepath.Join(dir, "pkg/__snapshots__/TestB_1.snap.new"),
+			},
+		}, pending)
+		test.False(t, pending[0].IsNew())
+		test.True(t, pending[1].IsNew())
+	})
+
+	t.Run("should find pending snapshots in custom and nested snapshot dirs", func(t *testing.T) {
+		dir := setupSnapsDir(t, "pkg/testdata/TestA/sub_1.json.new", "pkg/testdata/TestB_1.snap.txt.new", "pkg/a.json.new")
+
+		pending, err := FindPendingSnapshots(dir, "testdata", nil)
+
+		test.NoError(t, err)
+		test.Equal(t, []PendingSnapshot{
+			{
+				Path:        filepath.Join(dir, "pkg/testdata/TestA/sub_1.json"),
+				PendingPath: filepath.Join(dir, "pkg/testdata/TestA/sub_1.json.new"),
+			},
+			{
+				Path:        filepath.Join(dir, "pkg/testdata/TestB_1.snap.txt"),
+				PendingPath: filepath.Join(dir, "pkg/testdata/TestB_1.snap.txt.new"),
+			},
+		}, pending)
+	})
+
+	t.Run("should filter pending snapshots", func(t *testing.T) {
+		dir := setup(t)
+
+		pending, err := FindPendingSnapshots(dir, "", regexp.MustCompile("TestB"))
+
+		test.NoError(t, err)
+		test.Equal(t, 1, len(pending))
+		test.Equal(t, filepath.Join(dir, "pkg/__snapshots__/TestB_1.snap"), pending[0].Path)
+	})
+
+	t.Run("should render diff against accepted snapshot", func(t *testing.T) {
+		dir := setup(t)
+		pending, _ := FindPendingSnapshots(dir, "", nil)
+
+		diff, err := pending[0].Diff("TestA_1.snap")
+
+		test.NoError(t, err)
+		test.Contains(t, diff, "- mock-snapshot")
+		test.Contains(t, diff, "+ new value")
+		test.Contains(t, diff, "at TestA_1.snap:1")
+	})
+
+	t.Run("should ignore headers in diff", func(t *testing.T) {
+		dir := setup(t)
+		pending, _ := FindPendingSnapshots(dir, "", nil)
+		header := SnapshotHeader{Test: "TestA", Caller: "a_test.go:10", Format: "pretty", Version: "v1.0.0"}
+		_ = os.WriteFile(pending[0].Path, []byte(header.String()+"new value"), os.ModePerm)
+		header.Caller, header.Version = "a_test.go:12", "v1.1.0"
+		_ = os.WriteFile(pending[0].PendingPath, []byte(header.String()+"new value"), os.ModePerm)
+
+		diff, err := pending[0].Diff("TestA_1.snap")
+
+		test.NoError(t, err)
+		test.Equal(t, "", diff)
+
+		_ = os.WriteFile(pending[0].PendingPath, []byte("newer value"), os.ModePerm)
+
+		diff, err = pending[0].Diff("TestA_1.snap")
+
+		test.NoError(t, err)
+		test.Contains(t, diff, "- new value")
+		test.Contains(t, diff, "+ newer value")
+		test.False(t, strings.Contains(diff, "version"))
+	})
+
+	t.Run("should accept and reject", func(t *testing.T) {
+		dir := setup(t)
+		pending, _ := FindPendingSnapshots(dir, "", nil)
+
+		test.NoError(t, pending[0].Accept())
+		test.NoError(t, pending[1].Reject())
+
+		test.Equal(t, "new value", test.GetFileContent(t, pending[0].Path))
+		test.True(t, pending[1].IsNew())
+		remaining, _ := FindPendingSnapshots(dir, "", nil)
+		test.Equal(t, 0, len(remaining))
+	})
+}
@@ -0,0 +1,88 @@
+package snaps
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Serializer renders a value in snapshots, replacing the default kr/pretty output
+type Serializer interface {
+	Serialize(v any) (string, error)
+}
+
+// SerializerFunc is a function implementing Serializer
+//
+//	snaps.SerializerFor[Money](snaps.SerializerFunc(func(v any) (string, error) {
+//		m := v.(Money)
+//		return fmt.Sprintf("%d.%02d %s", m.Cents/100, m.Cents%100, m.Currency), nil
+//	}))
+type SerializerFunc func(v any) (string, error)
+
+func (f SerializerFunc) Serialize(v any) (string, error) { return f(v) }
+
+// Serializers for values implementing common interfaces, to be registered for the interface e.g.
+//
+//	snaps.WithConfig(snaps.SerializerFor[fmt.Stringer](snaps.StringerSerializer))
+//
+// They are opt-in, no serializer is applied unless registered: applying them by default would change
+// the existing snapshots of every value implementing these interfaces e.g. time.Time.
+var (
+	// StringerSerializer renders fmt.Stringer values with String
+	StringerSerializer Serializer = SerializerFunc(func(v any) (string, error) {
+		return v.(fmt.Stringer).String(), nil
+	})
+	// TextSerializer renders encoding.TextMarshaler values with MarshalText
+	TextSerializer Serializer = SerializerFunc(func(v any) (string, error) {
+		b, err := v.(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	})
+	// JSONSerializer renders json.Marshaler values, or any value, as indented json
+	JSONSerializer Serializer = SerializerFunc(func(v any) (string, error) {
+		b, err := json.MarshalIndent(v, "", " ")
+		return string(b), err
+	})
+)
+
+// SerializerFor Specify how values of type T are rendered in snapshots
+//
+//	snaps.WithConfig(snaps.SerializerFor[uuid.UUID](snaps.StringerSerializer)).MatchSnapshot(t, id)
+//
+// When T is an interface the serializer is a fallback for values implementing it, used when
+// no serializer is registered for their exact type. Fallbacks are tried in registration order.
+//
+// Serializers also apply to values nested in the snapshotted value e.g. struct fields, elements of
+// slices and keys or values of maps, the rest of the value is still rendered with kr/pretty.
+// Values in unexported fields are passed to serializers too, they must only be read.
+func SerializerFor[T any](s Serializer) func(*Config) {
+	return func(c *Config) {
+		typ := reflect.TypeFor[T]()
+		if c.serializers == nil {
+			c.serializers = make(map[reflect.Type]Serializer)
+		}
+		if _, ok := c.serializers[typ]; !ok && typ.Kind() == reflect.Interface {
+			c.serializerInterfaces = append(c.serializerInterfaces, typ)
+		}
+		c.serializers[typ] = s
+	}
+}
+
+// Serializer returns the serializer registered for the type of v, if any
+func (c *Config) Serializer(v any) (Serializer, bool) {
+	if v == nil || len(c.serializers) == 0 {
+		return nil, false
+	}
+
+	typ := reflect.TypeOf(v)
+	if s, ok := c.serializers[typ]; ok {
+		return s, true
+	}
+	for _, iface := range c.serializerInterfaces {
+		if typ.Implements(iface) {
+			return c.serializers[iface], true
+		}
+	}
+
+	return nil, false
+}
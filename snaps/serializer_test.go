@@ -0,0 +1,167 @@
+package snaps
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	valuePretty "github.com/kr/pretty"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type money struct {
+	cents    int
+	currency string
+}
+
+func (m money) String() string {
+	return fmt.Sprintf("%d.%02d %s", m.cents/100, m.cents%100, m.currency)
+}
+
+type textID string
+
+func (id textID) String() string { return "stringer " + string(id) }
+
+func (id textID) MarshalText() ([]byte, error) {
+	if id == "" {
+		return nil, errors.New("empty id")
+	}
+	return []byte("id:" + string(id)), nil
+}
+
+func TestSerializer(t *testing.T) {
+	t.Run("should use the serializer of the exact type", func(t *testing.T) {
+		c := WithConfig(
+			SerializerFor[fmt.Stringer](StringerSerializer),
+			SerializerFor[textID](TextSerializer),
+		)
+
+		snapshot, err := newSnapshotSerializer(c).takeSnapshot(textID("a"))
+		test.NoError(t, err)
+		test.Equal(t, "id:a", snapshot)
+	})
+
+	t.Run("should fall back to interfaces in registration order", func(t *testing.T) {
+		c := WithConfig(
+			SerializerFor[fmt.Stringer](StringerSerializer),
+			SerializerFor[encoding.TextMarshaler](TextSerializer),
+		)
+
+		snapshot, err := newSnapshotSerializer(c).takeSnapshot(textID("a"))
+		test.NoError(t, err)
+		test.Equal(t, "stringer a", snapshot)
+	})
+
+	t.Run("should apply to nested values", func(t *testing.T) {
+		type order struct {
+			Total money
+			Items []money
+			Taxes map[string]money
+			note  string
+		}
+		c := WithConfig(SerializerFor[money](StringerSerializer))
+
+		snapshot, err := newSnapshotSerializer(c).takeSliceSnapshot([]any{
+			money{1050, "EUR"},
+			order{
+				Total: money{1100, "EUR"},
+				Items: []money{{1000, "EUR"}, {50, "EUR"}},
+				Taxes: map[string]money{"vat": {50, "EUR"}},
+				note:  "gift",
+			},
+			nil,
+		})
+		test.NoError(t, err)
+		test.Equal(
+			t,
+			"10.50 EUR\nsnaps.order{\n    Total: 11.00 EUR,\n    Items: {\n        10.00 EUR,\n        0.50 EUR,\n    },\n    Taxes: {\n        \"vat\": 0.50 EUR,\n    },\n    note: \"gift\",\n}\nnil",
+			snapshot,
+		)
+	})
+
+	t.Run("should render like kr/pretty around serialized values", func(t *testing.T) {
+		type address struct {
+			Street string
+			number int
+		}
+		type user struct {
+			ID       int
+			Name     string
+			Email    *string
+			Tags     []string
+			Scores   map[string]int
+			Home     address
+			Work     *address
+			Previous []address
+			Rates    [2]float64
+			Active   bool
+			Created  time.Time
+			Extra    any
+			private  map[int][]int
+		}
+		email := "mock-user@email.com"
+		// ints are rendered as kr/pretty does outside of interfaces, so the output must not change
+		c := WithConfig(SerializerFor[int](SerializerFunc(func(v any) (string, error) {
+			return fmt.Sprint(v), nil
+		})))
+
+		for _, v := range []any{
+			user{
+				ID:       1,
+				Name:     `mock "user"`,
+				Email:    &email,
+				Tags:     []string{"a", "b"},
+				Scores:   map[string]int{"b": 2, "a": 1},
+				Home:     address{"main", 10},
+				Work:     &address{number: 20},
+				Previous: []address{{"old", 1}, {}},
+				Rates:    [2]float64{0.5, 1},
+				Created:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Extra:    address{"extra", 3},
+				private:  map[int][]int{2: {1}, 1: nil},
+			},
+			user{ID: 1},
+			[]user{{ID: 1, Tags: []string{}}, {ID: 2, Scores: map[string]int{}}},
+			map[string][]int{"a": {1, 2}, "b": nil},
+			&address{"main", 1},
+			[][]int{{1}, {2, 3}},
+		} {
+			snapshot, err := newSnapshotSerializer(c).takeSnapshot(v)
+
+			test.NoError(t, err)
+			test.Equal(t, valuePretty.Sprint(v), snapshot)
+		}
+	})
+
+	t.Run("should report errors of nested values", func(t *testing.T) {
+		c := WithConfig(SerializerFor[textID](TextSerializer))
+
+		_, err := newSnapshotSerializer(c).takeSnapshot([]textID{"a", ""})
+		test.Equal(t, "empty id", err.Error())
+	})
+
+	t.Run("should render json", func(t *testing.T) {
+		c := WithConfig(SerializerFor[map[string]int](JSONSerializer))
+
+		snapshot, err := newSnapshotSerializer(c).takeSnapshot(map[string]int{"b": 2, "a": 1})
+		test.NoError(t, err)
+		test.Equal(t, "{\n \"a\": 1,\n \"b\": 2\n}", snapshot)
+	})
+
+	t.Run("should report serializer errors", func(t *testing.T) {
+		resetEnv(t)
+
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestSerializer")
+
+		WithConfig(Dir(dir), SerializerFor[textID](TextSerializer)).MatchSnapshot(mockT, textID(""))
+
+		test.Equal(t, 1, len(*errs))
+		test.Equal(t, "empty id", (*errs)[0].(error).Error())
+		_, err := os.Stat(filepath.Join(dir, "TestSerializer_1.snap"))
+		test.True(t, os.IsNotExist(err))
+	})
+}
@@ -10,10 +10,12 @@ import (
 	"github.com/KoNekoD/go-snaps/snaps/symbols"
 	"github.com/gkampitakis/ciinfo"
 	"github.com/tidwall/gjson"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -30,13 +32,16 @@ type TestingT interface {
 }
 
 var (
-	defaultSnap     = newSnap(defaultConfig(), nil)
 	defaultRegistry = newSnapRegistry()
 	isCI            = ciinfo.IsCI
 	updateVAR       = os.Getenv("UPDATE_SNAPS")
+	outputDirVAR    = os.Getenv("SNAPS_OUTPUT_DIR")
+	patchVAR        = os.Getenv("SNAPS_PATCH")
+	ciVAR           = os.Getenv("SNAPS_CI")
 	skippedMsg      = colors.Sprint(colors.Yellow, symbols.SkipSymbol+"Snapshot skipped")
 	addedMsg        = colors.Sprint(colors.Green, symbols.UpdateSymbol+"Snapshot added")
 	updatedMsg      = colors.Sprint(colors.Green, symbols.UpdateSymbol+"Snapshot updated")
+	pendingMsg      = colors.Sprint(colors.Yellow, symbols.UpdateSymbol+"Snapshot pending review at ")
 	errInvalidJSON  = errors.New("invalid json")
 	errSnapNotFound = errors.New("snapshot not found")
 )
@@ -46,44 +51,81 @@ const (
 	added
 	updated
 	passed
+	pending
+	warned
+	ignored
 )
 
+// pendingExtension is appended to the snapshot path of snapshots waiting for review e.g. `TestFoo_1.snap.new`
+const pendingExtension = ".new"
+
 type snapRegistry struct {
 	testEvents      map[uint8]int
 	testEventsMutex sync.Mutex
 
-	registryRunning map[string]int
-	registryCleanup map[string]int
-	registryMutex   sync.Mutex
+	registryRunning   map[string]int
+	registryCleanup   map[string]int            // snapshot paths produced during the run
+	registryDirs      map[string]map[string]int // snapshot dirs and the file extensions written in them
+	registryTests     map[string]int            // tests that produced or skipped snapshots
+	registryModes     map[UpdateMode]int        // update modes snapshots were handled with
+	registrySections  map[string]map[string]int // sections produced in snapshot files with the FilePerTestFile layout
+	registryStores    map[string]Store          // stores snapshot dirs were written with
+	registryNames     map[string]string         // names of named snapshots taken by running tests by file name
+	registryTemplates map[string]map[string]int // snapshot dirs and the filename templates used in them
+	registryMutex     sync.Mutex
+
+	registryPatches      map[string]*patchEntry // snapshot files failing snapshots would have changed
+	registrySources      map[string]int         // test sources inline snapshots were matched in
+	registryPatchesMutex sync.Mutex
+
+	skippedTests      []string
+	skippedFiles      map[string]int // test files, without extension, containing skipped tests
+	skippedTestsMutex sync.Mutex
 }
 
 func newSnapRegistry() *snapRegistry {
-	return &snapRegistry{testEvents: make(map[uint8]int), registryRunning: make(map[string]int), registryCleanup: make(map[string]int)}
+	return &snapRegistry{
+		testEvents:        make(map[uint8]int),
+		registryRunning:   make(map[string]int),
+		registryCleanup:   make(map[string]int),
+		registryDirs:      make(map[string]map[string]int),
+		registryTests:     make(map[string]int),
+		registryModes:     make(map[UpdateMode]int),
+		registrySections:  make(map[string]map[string]int),
+		registryStores:    make(map[string]Store),
+		registryNames:     make(map[string]string),
+		registryTemplates: make(map[string]map[string]int),
+		registryPatches:   make(map[string]*patchEntry),
+		registrySources:   make(map[string]int),
+		skippedTests:      make([]string, 0),
+		skippedFiles:      make(map[string]int),
+	}
 }
 
 type snap struct {
 	c                  *Config
 	t                  TestingT
 	fileExtension      string
-	skippedTests       []string
-	skippedTestsMutex  sync.Mutex
+	name               string // name of named snapshots, which aren't numbered
+	named              bool
+	legacyPath         string // snapshot read from its name before test names were sanitized, see legacySnapshotPath
+	format             string
 	registry           *snapRegistry
 	snapshotSerializer *snapshotSerializer
 }
 
 func newSnap(c *Config, t TestingT) *snap {
-	return &snap{c: c, t: t, skippedTests: make([]string, 0), registry: defaultRegistry, fileExtension: ".snap", snapshotSerializer: newSnapshotSerializer(c)}
-}
-
-func (s *snap) withTesting(t TestingT) *snap {
-	s.t = t
-
-	return s
+	return &snap{c: c, t: t, registry: defaultRegistry, fileExtension: ".snap", format: "pretty", snapshotSerializer: newSnapshotSerializer(c)}
 }
 
 func (s *snap) matchStandaloneSnapshot(v any) {
 	s.t.Helper()
-	s.handleSnapshot(s.snapshotSerializer.takeSnapshot(v))
+	snapshot, err := s.snapshotSerializer.takeSnapshot(v)
+	if err != nil {
+		s.handleError(err)
+		return
+	}
+	s.handleSnapshot(snapshot)
 }
 
 func (s *snap) matchSnapshot(v ...any) {
@@ -94,11 +136,17 @@ func (s *snap) matchSnapshot(v ...any) {
 		return
 	}
 
-	s.handleSnapshot(s.snapshotSerializer.takeSliceSnapshot(v))
+	snapshot, err := s.snapshotSerializer.takeSliceSnapshot(v)
+	if err != nil {
+		s.handleError(err)
+		return
+	}
+	s.handleSnapshot(snapshot)
 }
 
 func (s *snap) matchJson(input any, matchers ...matchers.JsonMatcher) {
 	s.fileExtension = ".json"
+	s.format = "json"
 	s.t.Helper()
 
 	v, err := s.validateJson(input)
@@ -109,11 +157,7 @@ func (s *snap) matchJson(input any, matchers ...matchers.JsonMatcher) {
 
 	v, matchersErrors := s.applyJsonMatchers(v, matchers...)
 	if len(matchersErrors) > 0 {
-		sb := strings.Builder{}
-		for _, err := range matchersErrors {
-			colors.Fprint(&sb, colors.Red, fmt.Sprintf("\n%smatch.%s(\"%s\") - %s", symbols.ErrorSymbol, err.Matcher, err.Path, err.Reason))
-		}
-		s.handleError(sb.String())
+		s.handleError(formatMatchersErrors(matchersErrors))
 		return
 	}
 
@@ -122,17 +166,41 @@ func (s *snap) matchJson(input any, matchers ...matchers.JsonMatcher) {
 
 func (s *snap) handleSnapshot(actualSerializedSnapshot string) {
 	s.t.Helper()
-	genericPathSnap, genericSnapPathRel := s.snapshotPath()
-	snapPath, snapPathRel := s.getTestIdFromRegistry(genericPathSnap, genericSnapPathRel)
+	if s.named {
+		if err := s.registerName(); err != nil {
+			s.handleError(err)
+			return
+		}
+	}
+	genericPathSnap, genericSnapPathRel, err := s.snapshotPath()
+	if err != nil {
+		s.handleError(err)
+		return
+	}
+	snapPath, snapPathRel, section := s.getTestIdFromRegistry(genericPathSnap, genericSnapPathRel)
 	s.t.Cleanup(func() { s.resetSnapPathInRegistry(genericPathSnap) })
+	mode := s.updateMode()
+	s.registerUpdateMode(mode)
 
-	fileBytes, err := os.ReadFile(snapPath)
+	savedRawSnapshot, err := s.readSnapshot(snapPath, section)
+	if errors.Is(err, fs.ErrNotExist) && section == "" {
+		savedRawSnapshot, err = s.readLegacySnapshot(snapPath, err)
+	}
 	if err != nil {
-		if isCI {
-			s.handleError(errSnapNotFound)
+		if !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, errSnapNotFound) {
+			s.handleError(err)
 			return
 		}
-		err := s.upsertStandaloneSnapshot(actualSerializedSnapshot, snapPath)
+		if mode == UpdatePending {
+			s.handlePending(errSnapNotFound.Error()+"\n", actualSerializedSnapshot, snapPath, snapPathRel, section)
+			return
+		}
+		if !mode.writesNew() {
+			s.registerPatch(actualSerializedSnapshot, snapPath, section)
+			s.handleFailure(s.c.CI().Missing, errSnapNotFound)
+			return
+		}
+		err := s.writeSnapshot(actualSerializedSnapshot, snapPath, section)
 		if err != nil {
 			s.handleError(err)
 			return
@@ -141,7 +209,7 @@ func (s *snap) handleSnapshot(actualSerializedSnapshot string) {
 		s.registerTestEvent(added)
 		return
 	}
-	savedSerializedSnapshot := string(fileBytes)
+	_, savedSerializedSnapshot, _ := ParseHeader(savedRawSnapshot)
 
 	// savedSerializedSnapshot ( Unmarshall and Marshall again )
 	var savedSnapshot map[string]interface{}
@@ -163,57 +231,121 @@ func (s *snap) handleSnapshot(actualSerializedSnapshot string) {
 	}
 
 	prettyDiff := ""
-	if expected != received && !(reflect.DeepEqual(savedSnapshotRaw, actualSnapshotRaw) && successfullyDeserialized) {
-		differ := getUnifiedDiff
-		if shouldPrintHighlights(expected, received) {
-			differ = singlelineDiff
-		}
-		finalDiff, i, d := differ(expected, received)
+	if expected != received && !(reflect.DeepEqual(savedSnapshotRaw, actualSnapshotRaw) && successfullyDeserialized) &&
+		!(s.format == "yaml" && yamlEqual(expected, received)) && !(s.format == "xml" && xmlEqual(expected, received)) &&
+		!(s.format == "html" && htmlEqual(expected, received, s.c.HTML())) {
 		_ = diff.Diff(expected, received) // TODO: Add possibility to change diff printer, now alternative is disabled
-		prettyDiff = buildDiffReport(i, d, finalDiff, snapPathRel, 1)
+		if s.format == "html" {
+			prettyDiff = buildHTMLDiff(expected, received, snapPathRel, 1)
+		} else {
+			prettyDiff = buildPrettyDiff(expected, received, snapPathRel, 1)
+		}
 	}
 	if prettyDiff == "" {
+		if mode == UpdatePending && section == "" {
+			_ = s.c.Store().Delete(snapPath + pendingExtension) // the snapshot matches again, the pending one is stale
+		}
+		if mode.writesPassing() && (savedRawSnapshot != s.withHeader(actualSerializedSnapshot) || s.legacyPath != "") {
+			s.updateSnapshot(actualSerializedSnapshot, snapPath, section)
+			return
+		}
 		s.registerTestEvent(passed)
 		return
 	}
-	if !s.shouldUpdate() {
-		s.handleError(prettyDiff)
+	if mode == UpdatePending {
+		s.handlePending(prettyDiff, actualSerializedSnapshot, snapPath, snapPathRel, section)
 		return
 	}
-	if err = s.upsertStandaloneSnapshot(actualSerializedSnapshot, snapPath); err != nil {
+	if !mode.writesFailing() {
+		s.registerPatch(actualSerializedSnapshot, snapPath, section)
+		s.handleFailure(s.c.CI().Mismatch, prettyDiff)
+		return
+	}
+	s.updateSnapshot(actualSerializedSnapshot, snapPath, section)
+}
+
+func (s *snap) updateSnapshot(snapshot, snapPath, section string) {
+	s.t.Helper()
+	if err := s.writeSnapshot(snapshot, snapPath, section); err != nil {
 		s.handleError(err)
 		return
 	}
+	if s.legacyPath != "" {
+		_ = s.c.Store().Delete(s.legacyPath) // moved to the sanitized name
+	}
 	s.t.Log(updatedMsg)
 	s.registerTestEvent(updated)
 }
 
-func (s *snap) snapshotPath() (string, string) {
+func (s *snap) snapshotPath() (string, string, error) {
 	s.t.Helper()
 	callerFilename := s.baseCaller(4) //  skips current func, the wrapper match* and the exported Match* func
+	if resolved, err := callerFile(callerFilename); err == nil {
+		callerFilename = resolved
+	} else if !filepath.IsAbs(s.c.SnapsDir()) {
+		return "", "", err
+	}
 	dir := s.c.SnapsDir()
 	if !filepath.IsAbs(dir) {
 		dir = filepath.Join(filepath.Dir(callerFilename), s.c.SnapsDir())
 	}
-	snapPath := filepath.Join(dir, s.constructFilename(callerFilename))
-	snapPathRel, _ := filepath.Rel(filepath.Dir(callerFilename), snapPath)
+	if s.c.FilenameTemplate() != "" {
+		s.registerTemplate(dir)
+	}
+	relDir, _ := filepath.Rel(filepath.Dir(callerFilename), dir)
+	if s.numbered() {
+		dir, relDir = escapePercent(dir), escapePercent(relDir)
+	}
+	filename := s.constructFilename(callerFilename)
 
-	return snapPath, snapPathRel
+	return filepath.Join(dir, filename), filepath.Join(relDir, filename), nil
 }
 
+// numbered reports whether snapshot paths contain the call counter, in which case they are
+// formatted with fmt.Sprintf and every other `%` is escaped
+func (s *snap) numbered() bool {
+	return !s.named && s.c.Layout() != FilePerTestFile
+}
+
+func escapePercent(s string) string { return strings.ReplaceAll(s, "%", "%%") }
+
 func (s *snap) constructFilename(callerFilename string) string {
 	filename := s.c.Filename()
+	if s.c.Layout() == FilePerTestFile {
+		if filename == "" {
+			base := filepath.Base(callerFilename)
+			filename = strings.TrimSuffix(base, filepath.Ext(base))
+		}
+		return filename + s.snapshotExtension()
+	}
+	if filename == "" && s.c.FilenameTemplate() != "" {
+		return s.expandTemplate(callerFilename) + s.snapshotExtension()
+	}
 	if filename == "" {
 		base := filepath.Base(callerFilename)
 		filename = strings.TrimSuffix(base, filepath.Ext(base))
-		filename = strings.ReplaceAll(s.t.Name(), "/", "_")
+		filename = testFilename(s.t.Name())
+	}
+	if s.named {
+		filename += "_" + testFilename(s.name)
+	} else {
+		filename = escapePercent(filename) + "_%d"
 	}
-	filename += "_%d"
-	filename += s.fileExtension + s.c.Extension()
+	filename += s.snapshotExtension()
 
 	return filename
 }
 
+// snapshotExtension returns the extension of snapshot files e.g. `.snap` or `.json`,
+// files with the FilePerTestFile layout always use `.snap` as they mix snapshot formats
+func (s *snap) snapshotExtension() string {
+	if s.c.Layout() == FilePerTestFile {
+		return ".snap" + s.c.Extension()
+	}
+
+	return s.fileExtension + s.c.Extension()
+}
+
 func (s *snap) applyJsonMatchers(b []byte, matchersList ...matchers.JsonMatcher) ([]byte, []matchers.MatcherError) {
 	var matcherErrors []matchers.MatcherError
 
@@ -229,15 +361,24 @@ func (s *snap) applyJsonMatchers(b []byte, matchersList ...matchers.JsonMatcher)
 	return b, matcherErrors
 }
 
-func (s *snap) shouldUpdate() bool {
-	if isCI {
-		return false
-	}
-	if u := s.c.Update(); u != nil {
-		return *u
+func formatMatchersErrors(matchersErrors []matchers.MatcherError) string {
+	sb := strings.Builder{}
+	for _, err := range matchersErrors {
+		colors.Fprint(&sb, colors.Red, fmt.Sprintf("\n%smatch.%s(\"%s\") - %s", symbols.ErrorSymbol, err.Matcher, err.Path, err.Reason))
 	}
 
-	return "true" == updateVAR
+	return sb.String()
+}
+
+// handlePending writes the received snapshot next to the original for review and fails the test
+func (s *snap) handlePending(report, snapshot, snapPath, snapPathRel, section string) {
+	s.t.Helper()
+	if err := s.writePendingSnapshot(snapshot, snapPath, section); err != nil {
+		s.handleError(err)
+		return
+	}
+	s.t.Error(report + pendingMsg + snapPathRel + pendingExtension)
+	s.registerTestEvent(pending)
 }
 
 func (s *snap) validateJson(input any) ([]byte, error) {
@@ -265,46 +406,94 @@ func (s *snap) handleError(err any) {
 	s.registerTestEvent(erred)
 }
 
-func (s *snap) upsertStandaloneSnapshot(snapshot, snapPath string) error {
-	if err := os.MkdirAll(filepath.Dir(snapPath), os.ModePerm); err != nil {
-		return err
+// readSnapshot returns the stored snapshot, section is empty for snapshots stored in their own file
+func (s *snap) readSnapshot(snapPath, section string) (string, error) {
+	if section != "" {
+		return readMultiSnapshot(s.c.Store(), snapPath, section)
+	}
+
+	b, err := s.c.Store().Read(snapPath)
+	return string(b), err
+}
+
+func (s *snap) writeSnapshot(snapshot, snapPath, section string) error {
+	snapshot = s.withHeader(snapshot)
+	if section != "" {
+		return upsertMultiSnapshot(s.c.Store(), snapshot, snapPath, snapPath, section)
+	}
+
+	return s.upsertStandaloneSnapshot(snapshot, snapPath)
+}
+
+// writePendingSnapshot writes the snapshot for review, for snapshot files with multiple sections
+// the pending file contains the whole file with the section replaced
+func (s *snap) writePendingSnapshot(snapshot, snapPath, section string) error {
+	snapshot = s.withHeader(snapshot)
+	if section == "" {
+		return s.upsertStandaloneSnapshot(snapshot, snapPath+pendingExtension)
+	}
+
+	base := snapPath + pendingExtension
+	if _, err := s.c.Store().Read(base); err != nil {
+		base = snapPath
 	}
-	return os.WriteFile(snapPath, []byte(snapshot), os.ModePerm)
+
+	return upsertMultiSnapshot(s.c.Store(), snapshot, base, snapPath+pendingExtension, section)
+}
+
+func (s *snap) upsertStandaloneSnapshot(snapshot, snapPath string) error {
+	return s.c.Store().Write(snapPath, []byte(snapshot))
 }
 
 func (s *snap) trackSkip() {
 	s.t.Helper()
 	s.t.Log(skippedMsg)
-	s.skippedTestsMutex.Lock()
-	s.skippedTests = append(s.skippedTests, s.t.Name())
-	s.skippedTestsMutex.Unlock()
+	s.registry.skippedTestsMutex.Lock()
+	s.registry.skippedTests = append(s.registry.skippedTests, s.t.Name())
+	s.registry.skippedTestsMutex.Unlock()
+
+	s.registry.registryMutex.Lock()
+	s.registry.registryTests[s.t.Name()]++
+	s.registry.registryMutex.Unlock()
+
+	base := filepath.Base(s.baseCaller(2))
+	s.registry.skippedTestsMutex.Lock()
+	s.registry.skippedFiles[strings.TrimSuffix(base, filepath.Ext(base))]++
+	s.registry.skippedTestsMutex.Unlock()
 }
 
 func (s *snap) baseCaller(skip int) string {
+	file, _ := s.baseCallerLine(skip + 1)
+	return file
+}
+
+// baseCallerLine returns the file and line of the closest caller in a _test.go file
+func (s *snap) baseCallerLine(skip int) (string, int) {
 	var (
 		pc             uintptr
 		file, prevFile string
+		line, prevLine int
 		ok             bool
 	)
 
 	for i := skip + 1; ; i++ {
-		prevFile = file
-		pc, file, _, ok = runtime.Caller(i)
+		prevFile, prevLine = file, line
+		pc, file, line, ok = runtime.Caller(i)
 		if !ok {
-			return prevFile
+			return prevFile, prevLine
 		}
 
 		f := runtime.FuncForPC(pc)
 		if f == nil {
-			return prevFile
+			return prevFile, prevLine
 		}
 
 		if f.Name() == "testing.tRunner" {
-			return prevFile
+			return prevFile, prevLine
 		}
 
 		if strings.HasSuffix(filepath.Base(file), "_test.go") {
-			return file
+			return file, line
 		}
 	}
 }
@@ -315,18 +504,57 @@ func (s *snap) registerTestEvent(event uint8) {
 	s.registry.testEvents[event]++
 }
 
-func (s *snap) getTestIdFromRegistry(snapPath, snapPathRel string) (string, string) {
+// getTestIdFromRegistry numbers the snapshot within the test unless it's named, returning the snapshot path and
+// for the FilePerTestFile layout the section of the file e.g. `TestFoo - 2` or `TestFoo - total`
+func (s *snap) getTestIdFromRegistry(snapPath, snapPathRel string) (string, string, string) {
 	s.registry.registryMutex.Lock()
-	s.registry.registryRunning[snapPath]++
+	defer s.registry.registryMutex.Unlock()
+
+	id, n := s.name, 0
+	if !s.named {
+		key := s.registryKey(snapPath)
+		s.registry.registryRunning[key]++
+		n = s.registry.registryRunning[key]
+		id = strconv.Itoa(n)
+	}
+
+	section := ""
+	if s.c.Layout() == FilePerTestFile {
+		section = fmt.Sprintf("%s - %s", s.t.Name(), id)
+		if s.registry.registrySections[snapPath] == nil {
+			s.registry.registrySections[snapPath] = make(map[string]int)
+		}
+		s.registry.registrySections[snapPath][section]++
+	} else if !s.named {
+		snapPath, snapPathRel = fmt.Sprintf(snapPath, n), fmt.Sprintf(snapPathRel, n)
+	}
+
+	dir := filepath.Dir(snapPath)
+	if s.registry.registryDirs[dir] == nil {
+		s.registry.registryDirs[dir] = make(map[string]int)
+	}
+	s.registry.registryDirs[dir][s.snapshotExtension()]++
+	if s.registry.registryStores[dir] == nil {
+		s.registry.registryStores[dir] = s.c.Store()
+	}
 	s.registry.registryCleanup[snapPath]++
-	c := s.registry.registryRunning[snapPath]
-	s.registry.registryMutex.Unlock()
+	s.registry.registryTests[s.t.Name()]++
+
+	return snapPath, snapPathRel, section
+}
+
+// registryKey returns the key snapshots are numbered by, snapshots files shared by multiple tests
+// are numbered per test
+func (s *snap) registryKey(snapPath string) string {
+	if s.c.Layout() == FilePerTestFile {
+		return snapPath + "#" + s.t.Name()
+	}
 
-	return fmt.Sprintf(snapPath, c), fmt.Sprintf(snapPathRel, c)
+	return snapPath
 }
 
 func (s *snap) resetSnapPathInRegistry(snapPath string) {
 	s.registry.registryMutex.Lock()
-	s.registry.registryRunning[snapPath] = 0
+	s.registry.registryRunning[s.registryKey(snapPath)] = 0
 	s.registry.registryMutex.Unlock()
 }
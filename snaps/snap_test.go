@@ -2,7 +2,9 @@ package snaps
 
 import (
 	"fmt"
+	"github.com/KoNekoD/go-snaps/internal/test"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -152,7 +154,9 @@ func TestMatchJsonStructUpdateExisting(t *testing.T) {
 	}
 
 	// The second step of this test update snapshot - there are problems on restart if you don't delete the file
-	firstSnapPath := fmt.Sprintf(defaultSnap.withTesting(t).constructFilename(defaultSnap.baseCaller(3)), 1)
+	s := newSnap(defaultConfig(), t)
+	s.fileExtension = ".json"
+	firstSnapPath := fmt.Sprintf(s.constructFilename(s.baseCaller(3)), 1)
 	if _, err := os.Stat("__snapshots__/" + firstSnapPath); err == nil {
 		_ = os.Remove("__snapshots__/" + firstSnapPath)
 	}
@@ -161,8 +165,11 @@ func TestMatchJsonStructUpdateExisting(t *testing.T) {
 
 	u.Age = 30
 
-	defaultSnap.registry = newSnapRegistry() // 1/2: reset the registry
+	defaultRegistry.registryMutex.Lock() // 1/2: reset the snapshot counters
+	clear(defaultRegistry.registryRunning)
+	defaultRegistry.registryMutex.Unlock()
 
+	resetEnv(t)
 	updateVAR = "true" // 2/2: activate update snapshots mode
 
 	MatchJSON(t, u)
@@ -214,8 +221,9 @@ func TestMatchJsonStructWithReallyChangedJsonButStructureIsSameAndItDeepEqual(t
 	}
 
 	// Although the result and this modified result are different in terms of rows, they are the same inside the data
-	defaultSnap.fileExtension = ".json"
-	firstSnapPath := fmt.Sprintf(defaultSnap.withTesting(t).constructFilename(defaultSnap.baseCaller(3)), 1)
+	s := newSnap(defaultConfig(), t)
+	s.fileExtension = ".json"
+	firstSnapPath := fmt.Sprintf(s.constructFilename(s.baseCaller(3)), 1)
 	forcedSnapshot := []byte(`{"age":29,"email":"mock-user@email.com","id":1,"name":"mock-user","preferences":{"design":{"background":"blue","font":"serif"},"language":"en","theme":"dark"}}`)
 	_ = os.WriteFile("__snapshots__/"+firstSnapPath, forcedSnapshot, os.ModePerm)
 
@@ -268,15 +276,22 @@ func TestMatchFail(t *testing.T) {
 	}
 
 	// Although the result and this modified result are different in terms of rows, they are the same inside the data
-	defaultSnap.fileExtension = ".json"
-	firstSnapPath := fmt.Sprintf(defaultSnap.withTesting(t).constructFilename(defaultSnap.baseCaller(3)), 1)
+	s := newSnap(defaultConfig(), t)
+	s.fileExtension = ".json"
+	firstSnapPath := fmt.Sprintf(s.constructFilename(s.baseCaller(3)), 1)
 	forcedSnapshot := []byte(`{"age":30,"email":"mock-user@email.com","id":1,"name":"mock-user","preferences":{"design":{"background":"blue","font":"serif"},"language":"en","theme":"dark"}}`)
 	_ = os.WriteFile("__snapshots__/"+firstSnapPath, forcedSnapshot, os.ModePerm)
 
+	resetEnv(t)
+	updateVAR = "true" // the forced snapshot differs, it is updated
+
 	MatchJSON(t, u)
 }
 
 func TestMatchUnorderedList(t *testing.T) {
+	resetEnv(t)
+	updateVAR = "true" // the keys are in random order, the snapshot is updated on every run
+
 	keys := make([]string, 0)
 
 	keysMap := map[string]string{"1": "1", "2": "2", "3": "3", "4": "4", "5": "5", "6": "6"}
@@ -289,3 +304,67 @@ func TestMatchUnorderedList(t *testing.T) {
 		Keys []string `json:"keys"`
 	}{Keys: keys})
 }
+
+func TestMatchPending(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should write missing snapshot for review and fail", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestPending")
+
+		WithConfig(Dir(dir), Mode(UpdatePending)).MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, 1, len(*errs))
+		test.Contains(t, fmt.Sprint((*errs)[0]), "TestPending_1.snap.new")
+		test.Equal(t, "hello world", test.GetFileContent(t, filepath.Join(dir, "TestPending_1.snap.new")))
+		_, err := os.Stat(filepath.Join(dir, "TestPending_1.snap"))
+		test.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should write mismatching snapshot for review and keep the original", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestPending")
+		_ = os.WriteFile(filepath.Join(dir, "TestPending_1.snap"), []byte("hello world"), os.ModePerm)
+
+		WithConfig(Dir(dir), Mode(UpdatePending)).MatchSnapshot(mockT, "hello universe")
+
+		test.Equal(t, 1, len(*errs))
+		test.Equal(t, "hello world", test.GetFileContent(t, filepath.Join(dir, "TestPending_1.snap")))
+		test.Equal(t, "hello universe", test.GetFileContent(t, filepath.Join(dir, "TestPending_1.snap.new")))
+	})
+
+	t.Run("should remove stale pending snapshot when matching", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestPending")
+		_ = os.WriteFile(filepath.Join(dir, "TestPending_1.snap"), []byte("hello world"), os.ModePerm)
+		_ = os.WriteFile(filepath.Join(dir, "TestPending_1.snap.new"), []byte("hello universe"), os.ModePerm)
+
+		WithConfig(Dir(dir), Mode(UpdatePending)).MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, 0, len(*errs))
+		_, err := os.Stat(filepath.Join(dir, "TestPending_1.snap.new"))
+		test.True(t, os.IsNotExist(err))
+	})
+}
+
+func TestMatchParallel(t *testing.T) {
+	t.Run("group", func(t *testing.T) {
+		for i := range 20 {
+			t.Run(fmt.Sprintf("case_%d", i), func(t *testing.T) {
+				t.Parallel()
+
+				MatchSnapshot(t, "snapshot", i)
+				MatchJSON(t, map[string]int{"case": i})
+				MatchStandaloneSnapshot(t, i)
+			})
+		}
+	})
+
+	for i := range 20 {
+		name := filepath.Join("__snapshots__", fmt.Sprintf("TestMatchParallel_group_case_%d", i))
+
+		test.Equal(t, fmt.Sprintf("snapshot\nint(%d)", i), test.GetFileContent(t, name+"_1.snap"))
+		test.Equal(t, fmt.Sprintf("{\n \"case\": %d\n}", i), test.GetFileContent(t, name+"_1.json"))
+		test.Equal(t, fmt.Sprintf("int(%d)", i), test.GetFileContent(t, name+"_2.snap"))
+	}
+}
@@ -1,8 +1,9 @@
 package snaps
 
 import (
-	valuePretty "github.com/kr/pretty"
+	"bytes"
 	jsonPretty "github.com/tidwall/pretty"
+	"gopkg.in/yaml.v3"
 	"strings"
 )
 
@@ -18,14 +19,35 @@ func (s *snapshotSerializer) takeJsonSnapshot(b []byte) string {
 	return strings.TrimSuffix(string(jsonPretty.PrettyOptions(b, &jsonPretty.Options{SortKeys: s.c.SortProperties(), Indent: " "})), "\n")
 }
 
-func (s *snapshotSerializer) takeSnapshot(object any) string {
-	return valuePretty.Sprint(object)
+func (s *snapshotSerializer) takeYAMLSnapshot(docs []*yaml.Node) (string, error) {
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		normalizeYAML(doc, s.c.SortProperties())
+		if err := encoder.Encode(doc); err != nil {
+			return "", err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func (s *snapshotSerializer) takeSnapshot(object any) (string, error) {
+	return prettySprint(s.c, object)
 }
 
-func (s *snapshotSerializer) takeSliceSnapshot(objects []any) string {
+func (s *snapshotSerializer) takeSliceSnapshot(objects []any) (string, error) {
 	snapshots := make([]string, len(objects))
 	for i, object := range objects {
-		snapshots[i] = valuePretty.Sprint(object)
+		snapshot, err := s.takeSnapshot(object)
+		if err != nil {
+			return "", err
+		}
+		snapshots[i] = snapshot
 	}
-	return strings.Join(snapshots, "\n")
+	return strings.Join(snapshots, "\n"), nil
 }
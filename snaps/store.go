@@ -0,0 +1,287 @@
+package snaps
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var errReadOnlyStore = errors.New("snapshot store is read-only")
+
+const (
+	filePerm fs.FileMode = 0o644
+	dirPerm  fs.FileMode = 0o755
+)
+
+// Store reads and writes snapshot files.
+//
+// Paths are the snapshot paths resolved from the test file and `Dir`.
+// Read must return an error matching fs.ErrNotExist for missing snapshots.
+type Store interface {
+	Read(path string) ([]byte, error)
+	Write(path string, data []byte) error
+	Delete(path string) error
+	// List returns the names of the files directly inside dir
+	List(dir string) ([]string, error)
+}
+
+// DirLister is implemented by stores able to list nested dirs, Clean uses it to find the snapshots
+// of removed tests in the dirs a `FilenameTemplate` creates
+type DirLister interface {
+	// ListDirs returns the names of the dirs directly inside dir
+	ListDirs(dir string) ([]string, error)
+}
+
+// listDirs returns the dirs inside dir if the store supports it
+func listDirs(store Store, dir string) ([]string, error) {
+	if l, ok := store.(DirLister); ok {
+		return l.ListDirs(dir)
+	}
+
+	return nil, nil
+}
+
+type diskStore struct{}
+
+// NewDiskStore returns the default store, reading and writing snapshots on the filesystem
+func NewDiskStore() Store { return diskStore{} }
+
+func (diskStore) Read(path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+// Write replaces the file atomically, writing to a temp file in the same dir and renaming it,
+// so interrupted runs never leave truncated snapshots behind
+func (diskStore) Write(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), filePerm); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Delete removes the file, or the directory if it's empty
+func (diskStore) Delete(path string) error {
+	return os.Remove(path)
+}
+
+func (diskStore) List(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+func (diskStore) ListDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// MemoryStore keeps snapshots in memory, useful for testing helpers built on top of go-snaps
+// without touching the filesystem.
+type MemoryStore struct {
+	files map[string][]byte
+	mu    sync.Mutex
+}
+
+// NewMemoryStore returns an empty MemoryStore
+func NewMemoryStore() *MemoryStore { return &MemoryStore{files: make(map[string][]byte)} }
+
+func (m *MemoryStore) Read(path string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.files[filepath.Clean(path)]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: path, Err: fs.ErrNotExist}
+	}
+
+	return append([]byte(nil), data...), nil
+}
+
+func (m *MemoryStore) Write(path string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.files[filepath.Clean(path)] = append([]byte(nil), data...)
+	return nil
+}
+
+// Delete removes the file, deleting directories is a no-op as they only exist through their files
+func (m *MemoryStore) Delete(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.files, filepath.Clean(path))
+	return nil
+}
+
+func (m *MemoryStore) List(dir string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0)
+	for path := range m.files {
+		if filepath.Dir(path) == filepath.Clean(dir) {
+			names = append(names, filepath.Base(path))
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (m *MemoryStore) ListDirs(dir string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir = filepath.Clean(dir)
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for path := range m.files {
+		rel, err := filepath.Rel(dir, filepath.Dir(path))
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		name, _, _ := strings.Cut(rel, string(filepath.Separator))
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+type fsStore struct {
+	fsys fs.FS
+	dir  string
+}
+
+// NewFSStore returns a read-only store serving snapshots from fsys, e.g. an embed.FS.
+//
+// dir is the directory fsys is rooted at, relative paths are resolved from the working directory,
+// which for tests is the package directory
+//
+//	//go:embed __snapshots__
+//	var snapshots embed.FS
+//
+//	snaps.WithConfig(snaps.Storage(snaps.NewFSStore(snapshots, "."))).MatchSnapshot(t, "hello world")
+func NewFSStore(fsys fs.FS, dir string) Store {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	return fsStore{fsys: fsys, dir: dir}
+}
+
+// name maps an OS path to a path inside fsys
+func (f fsStore) name(path string) (string, bool) {
+	rel, err := filepath.Rel(f.dir, path)
+	if err != nil {
+		return "", false
+	}
+	name := filepath.ToSlash(rel)
+
+	return name, fs.ValidPath(name)
+}
+
+func (f fsStore) Read(path string) ([]byte, error) {
+	name, ok := f.name(path)
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: path, Err: fs.ErrNotExist}
+	}
+
+	return fs.ReadFile(f.fsys, name)
+}
+
+func (fsStore) Write(string, []byte) error { return errReadOnlyStore }
+
+func (fsStore) Delete(string) error { return errReadOnlyStore }
+
+func (f fsStore) List(dir string) ([]string, error) {
+	name, ok := f.name(dir)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: dir, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+func (f fsStore) ListDirs(dir string) ([]string, error) {
+	name, ok := f.name(dir)
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: dir, Err: fs.ErrNotExist}
+	}
+	entries, err := fs.ReadDir(f.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
@@ -0,0 +1,162 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestDiskStore(t *testing.T) {
+	t.Run("should write files atomically with sane permissions", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "__snapshots__")
+		path := filepath.Join(dir, "TestA_1.snap")
+		store := NewDiskStore()
+
+		test.NoError(t, store.Write(path, []byte("first")))
+		test.NoError(t, store.Write(path, []byte("second")))
+
+		test.Equal(t, "second", test.GetFileContent(t, path))
+		names, err := store.List(dir)
+		test.NoError(t, err)
+		test.Equal(t, []string{"TestA_1.snap"}, names)
+
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(path)
+			test.NoError(t, err)
+			test.Equal(t, filePerm, info.Mode().Perm()&filePerm)
+			test.Equal(t, fs.FileMode(0), info.Mode().Perm()&0o111)
+		}
+	})
+
+	t.Run("should lock paths across open files", func(t *testing.T) {
+		if !lockSupported {
+			t.Skip("advisory locks are not supported on " + runtime.GOOS)
+		}
+		path := filepath.Join(t.TempDir(), "file_test.snap")
+		store := NewDiskStore().(Locker)
+
+		unlock, err := store.Lock(path)
+		test.NoError(t, err)
+
+		acquired := make(chan struct{})
+		go func() {
+			unlock, err := store.Lock(path)
+			test.NoError(t, err)
+			unlock()
+			close(acquired)
+		}()
+
+		select {
+		case <-acquired:
+			t.Fatal("lock acquired twice")
+		case <-time.After(50 * time.Millisecond):
+		}
+		unlock()
+		<-acquired
+
+		_, err = os.Stat(lockPath(path))
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("should read, list and delete written files", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		test.NoError(t, store.Write("/snaps/TestB_1.snap", []byte("b")))
+		test.NoError(t, store.Write("/snaps/TestA_1.snap", []byte("a")))
+		test.NoError(t, store.Write("/snaps/nested/TestC_1.snap", []byte("c")))
+
+		data, err := store.Read("/snaps/TestA_1.snap")
+		test.NoError(t, err)
+		test.Equal(t, "a", string(data))
+
+		names, err := store.List("/snaps")
+		test.NoError(t, err)
+		test.Equal(t, []string{"TestA_1.snap", "TestB_1.snap"}, names)
+
+		dirs, err := store.ListDirs("/snaps")
+		test.NoError(t, err)
+		test.Equal(t, []string{"nested"}, dirs)
+
+		test.NoError(t, store.Delete("/snaps/TestA_1.snap"))
+		_, err = store.Read("/snaps/TestA_1.snap")
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+}
+
+func TestFSStore(t *testing.T) {
+	fsys := fstest.MapFS{
+		"__snapshots__/TestA_1.snap": {Data: []byte("a")},
+		"__snapshots__/nested/x":     {Data: []byte("x")},
+	}
+	dir := t.TempDir()
+	store := NewFSStore(fsys, dir)
+
+	t.Run("should read files relative to its dir", func(t *testing.T) {
+		data, err := store.Read(filepath.Join(dir, "__snapshots__", "TestA_1.snap"))
+		test.NoError(t, err)
+		test.Equal(t, "a", string(data))
+
+		_, err = store.Read(filepath.Join(dir, "__snapshots__", "TestB_1.snap"))
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+
+		_, err = store.Read(filepath.Join(filepath.Dir(dir), "TestA_1.snap"))
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+	})
+
+	t.Run("should list files", func(t *testing.T) {
+		names, err := store.List(filepath.Join(dir, "__snapshots__"))
+		test.NoError(t, err)
+		test.Equal(t, []string{"TestA_1.snap"}, names)
+	})
+
+	t.Run("should be read-only", func(t *testing.T) {
+		test.Equal(t, errReadOnlyStore, store.Write(filepath.Join(dir, "__snapshots__", "TestA_1.snap"), nil))
+		test.Equal(t, errReadOnlyStore, store.Delete(filepath.Join(dir, "__snapshots__", "TestA_1.snap")))
+	})
+}
+
+func TestMatchWithStore(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should write snapshots to the memory store", func(t *testing.T) {
+		dir := t.TempDir()
+		store := NewMemoryStore()
+		c := WithConfig(Dir(dir), Storage(store))
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestStore")
+
+		c.MatchSnapshot(mockT, "hello world")
+
+		test.Equal(t, 0, len(*errs))
+		data, err := store.Read(filepath.Join(dir, "TestStore_1.snap"))
+		test.NoError(t, err)
+		test.Equal(t, "hello world", string(data))
+		_, err = os.Stat(filepath.Join(dir, "TestStore_1.snap"))
+		test.True(t, errors.Is(err, fs.ErrNotExist))
+
+		c.MatchSnapshot(mockT, "hello world")
+		test.Equal(t, 0, len(*errs))
+	})
+
+	t.Run("should compare against a read-only store", func(t *testing.T) {
+		dir := t.TempDir()
+		fsys := fstest.MapFS{"TestStore_1.snap": {Data: []byte("hello world")}}
+		c := WithConfig(Dir(dir), Storage(NewFSStore(fsys, dir)))
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestStore")
+
+		c.MatchSnapshot(mockT, "hello world")
+		test.Equal(t, 0, len(*errs))
+
+		mockT, errs = test.NewRecordingMockTestingT(t, "TestMissing")
+		c.MatchSnapshot(mockT, "missing")
+		test.Equal(t, 1, len(*errs))
+		test.Equal[any](t, errReadOnlyStore, (*errs)[0])
+	})
+}
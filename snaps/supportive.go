@@ -2,6 +2,8 @@ package snaps
 
 import (
 	"github.com/KoNekoD/go-snaps/snaps/matchers"
+	"net/http"
+	"net/http/httptest"
 )
 
 // MatchJSON verifies the input matches the most recent snap file.
@@ -19,7 +21,94 @@ import (
 func MatchJSON(t TestingT, input any, matchers ...matchers.JsonMatcher) {
 	t.Helper()
 
-	defaultSnap.withTesting(t).matchJson(input, matchers...)
+	newSnap(defaultConfig(), t).matchJson(input, matchers...)
+}
+
+// MatchYAML verifies the input matches the most recent yaml snap file, stored with the `.yaml` extension.
+// Input can be a yaml string or []byte, with one or more documents, or whatever value can be passed
+// successfully on `json.Marshal`, so json tags are respected as with Kubernetes objects.
+//
+//	MatchYAML(t, "user: mock-user\nage: 10\n")
+//	MatchYAML(t, deployment)
+//
+// Snapshots are written in block style with 2 spaces indentation and without comments, keys are
+// sorted with `SortProperties`. Snapshots match when they hold the same data regardless of formatting.
+//
+// MatchYAML supports the json matchers, paths are the same as for the json form of each document.
+//
+//	MatchYAML(t, "user: mock-user\ncreated: 2024-01-01\n", match.Any("created"))
+func MatchYAML(t TestingT, input any, matchers ...matchers.JsonMatcher) {
+	t.Helper()
+
+	newSnap(defaultConfig(), t).matchYAML(input, matchers...)
+}
+
+// MatchXML verifies the input matches the most recent xml snap file, stored with the `.xml` extension.
+// Input can be an xml string or []byte or whatever value can be passed successfully on `xml.Marshal`.
+//
+//	MatchXML(t, `<user id="10"><name>mock-user</name></user>`)
+//
+// Snapshots are written in a canonical form: indented with 2 spaces, every namespace declared on the
+// root element, attributes sorted, empty elements self-closed, without comments, processing instructions
+// and whitespace around text. Snapshots match when they are the same document regardless of formatting,
+// namespace prefixes and attribute order.
+//
+// MatchXML supports the Any, Type and Custom matchers, with paths being a subset of XPath matching
+// local names. The text of elements or the value of attributes is replaced.
+//
+//	MatchXML(t, response, match.Any("//Timestamp/Created", "//Assertion/@ID", "//Assertion/@IssueInstant"))
+func MatchXML(t TestingT, input any, matchers ...matchers.XMLMatcher) {
+	t.Helper()
+
+	newSnap(defaultConfig(), t).matchXML(input, matchers...)
+}
+
+// MatchHTML verifies the input matches the most recent html snap file, stored with the `.html` extension.
+// Input can be an html string or []byte, either a whole page or a fragment.
+//
+//	MatchHTML(t, `<ul class="menu"><li>Home</li><li class="active">Cart</li></ul>`)
+//
+// Snapshots are written with one element per line indented with 2 spaces, attributes sorted and whitespace
+// collapsed, except for the content of pre, textarea, script and style elements. Scripts, comments and
+// nonce attributes can be removed with `HTML`. Diffs list the elements that changed e.g. `ul.menu > li.active`.
+func MatchHTML(t TestingT, input any) {
+	t.Helper()
+
+	newSnap(defaultConfig(), t).matchHTML(input)
+}
+
+// MatchHTTPResponse verifies the response matches the most recent snap file, with the status line,
+// headers sorted by name and the body
+//
+//	resp, err := http.Get(server.URL + "/users/10")
+//	MatchHTTPResponse(t, resp)
+//	// HTTP/1.1 200 OK
+//	// Content-Type: application/json
+//	// Date: <redacted>
+//	//
+//	// {
+//	//  "id": 10
+//	// }
+//
+// Values of headers changing on every request e.g. Date, Set-Cookie and X-Request-Id are redacted, see `HTTP`
+// for others. Gzip encoded bodies are decompressed and json bodies pretty printed, respecting `SortProperties`.
+// The body is restored, so it can still be read after the call.
+func MatchHTTPResponse(t TestingT, resp *http.Response) {
+	t.Helper()
+
+	newSnap(defaultConfig(), t).matchHTTPResponse(resp)
+}
+
+// MatchHTTPRecorder verifies the response recorded by a handler matches the most recent snap file,
+// see `MatchHTTPResponse`
+//
+//	rec := httptest.NewRecorder()
+//	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/10", nil))
+//	MatchHTTPRecorder(t, rec)
+func MatchHTTPRecorder(t TestingT, rec *httptest.ResponseRecorder) {
+	t.Helper()
+
+	newSnap(defaultConfig(), t).matchHTTPResponse(rec.Result())
 }
 
 // MatchSnapshot verifies the values match the most recent snap file
@@ -36,7 +125,7 @@ func MatchJSON(t TestingT, input any, matchers ...matchers.JsonMatcher) {
 func MatchSnapshot(t TestingT, values ...any) {
 	t.Helper()
 
-	defaultSnap.withTesting(t).matchSnapshot(values...)
+	newSnap(defaultConfig(), t).matchSnapshot(values...)
 }
 
 // MatchStandaloneSnapshot verifies the value matches the most recent snap file
@@ -50,7 +139,54 @@ func MatchSnapshot(t TestingT, values ...any) {
 func MatchStandaloneSnapshot(t TestingT, value any) {
 	t.Helper()
 
-	defaultSnap.withTesting(t).matchStandaloneSnapshot(value)
+	newSnap(defaultConfig(), t).matchStandaloneSnapshot(value)
+}
+
+// MatchInline verifies the value matches the inline snapshot stored in the test source
+//
+//	MatchInline(t, "Hello World", snaps.Inline(`Hello World`))
+//
+// Pass nil for recording a new snapshot, the call gets rewritten on the next run
+//
+//	MatchInline(t, "Hello World", nil)
+//
+// When updating, the snaps.Inline literal is rewritten in place in the _test.go file.
+// Inline snapshots don't support the pending update mode.
+func MatchInline(t TestingT, value any, snapshot InlineSnapshot) {
+	t.Helper()
+
+	newSnap(defaultConfig(), t).matchInline(value, snapshot)
+}
+
+// MatchNamed verifies the values match the snapshot stored under the given name
+//
+//	MatchNamed(t, "checkout-total", cart.Total())
+//
+// Named snapshots are stored in `<TestName>_<name>.snap` instead of being numbered by call order,
+// so adding or removing assertions doesn't invalidate the snapshots after them.
+// Each name can be used once per test, names can't be numbers as they would collide with numbered snapshots.
+func MatchNamed(t TestingT, name string, values ...any) {
+	t.Helper()
+
+	newSnap(defaultConfig(), t).withName(name).matchSnapshot(values...)
+}
+
+// MatchNamedJSON verifies the input matches the json snapshot stored under the given name,
+// see `MatchJSON` and `MatchNamed`
+//
+//	MatchNamedJSON(t, "order", order, match.Any("created"))
+func MatchNamedJSON(t TestingT, name string, input any, matchers ...matchers.JsonMatcher) {
+	t.Helper()
+
+	newSnap(defaultConfig(), t).withName(name).matchJson(input, matchers...)
+}
+
+// MatchNamedStandalone verifies the value matches the snapshot stored under the given name,
+// see `MatchStandaloneSnapshot` and `MatchNamed`
+func MatchNamedStandalone(t TestingT, name string, value any) {
+	t.Helper()
+
+	newSnap(defaultConfig(), t).withName(name).matchStandaloneSnapshot(value)
 }
 
 // Skip Wrapper of testing.Skip
@@ -59,7 +195,7 @@ func MatchStandaloneSnapshot(t TestingT, value any) {
 func Skip(t TestingT, args ...any) {
 	t.Helper()
 
-	defaultSnap.withTesting(t).trackSkip()
+	newSnap(defaultConfig(), t).trackSkip()
 	t.Skip(args...)
 }
 
@@ -69,7 +205,7 @@ func Skip(t TestingT, args ...any) {
 func Skipf(t TestingT, format string, args ...any) {
 	t.Helper()
 
-	defaultSnap.withTesting(t).trackSkip()
+	newSnap(defaultConfig(), t).trackSkip()
 	t.Skipf(format, args...)
 }
 
@@ -79,7 +215,7 @@ func Skipf(t TestingT, format string, args ...any) {
 func SkipNow(t TestingT) {
 	t.Helper()
 
-	defaultSnap.withTesting(t).trackSkip()
+	newSnap(defaultConfig(), t).trackSkip()
 	t.SkipNow()
 }
 
@@ -101,6 +237,41 @@ func (c *Config) MatchJSON(t TestingT, input any, matchers ...matchers.JsonMatch
 	newSnap(c, t).matchJson(input, matchers...)
 }
 
+// MatchYAML verifies the input matches the most recent yaml snap file, see `MatchYAML`
+func (c *Config) MatchYAML(t TestingT, input any, matchers ...matchers.JsonMatcher) {
+	t.Helper()
+
+	newSnap(c, t).matchYAML(input, matchers...)
+}
+
+// MatchXML verifies the input matches the most recent xml snap file, see `MatchXML`
+func (c *Config) MatchXML(t TestingT, input any, matchers ...matchers.XMLMatcher) {
+	t.Helper()
+
+	newSnap(c, t).matchXML(input, matchers...)
+}
+
+// MatchHTML verifies the input matches the most recent html snap file, see `MatchHTML`
+func (c *Config) MatchHTML(t TestingT, input any) {
+	t.Helper()
+
+	newSnap(c, t).matchHTML(input)
+}
+
+// MatchHTTPResponse verifies the response matches the most recent snap file, see `MatchHTTPResponse`
+func (c *Config) MatchHTTPResponse(t TestingT, resp *http.Response) {
+	t.Helper()
+
+	newSnap(c, t).matchHTTPResponse(resp)
+}
+
+// MatchHTTPRecorder verifies the recorded response matches the most recent snap file, see `MatchHTTPResponse`
+func (c *Config) MatchHTTPRecorder(t TestingT, rec *httptest.ResponseRecorder) {
+	t.Helper()
+
+	newSnap(c, t).matchHTTPResponse(rec.Result())
+}
+
 // MatchSnapshot verifies the values match the most recent snap file
 // You can pass multiple values
 //
@@ -131,3 +302,50 @@ func (c *Config) MatchStandaloneSnapshot(t TestingT, value any) {
 
 	newSnap(c, t).matchStandaloneSnapshot(value)
 }
+
+// MatchInline verifies the value matches the inline snapshot stored in the test source
+//
+//	MatchInline(t, "Hello World", snaps.Inline(`Hello World`))
+//
+// Pass nil for recording a new snapshot, the call gets rewritten on the next run
+//
+//	MatchInline(t, "Hello World", nil)
+//
+// When updating, the snaps.Inline literal is rewritten in place in the _test.go file.
+// Inline snapshots don't support the pending update mode.
+func (c *Config) MatchInline(t TestingT, value any, snapshot InlineSnapshot) {
+	t.Helper()
+
+	newSnap(c, t).matchInline(value, snapshot)
+}
+
+// MatchNamed verifies the values match the snapshot stored under the given name
+//
+//	MatchNamed(t, "checkout-total", cart.Total())
+//
+// Named snapshots are stored in `<TestName>_<name>.snap` instead of being numbered by call order,
+// so adding or removing assertions doesn't invalidate the snapshots after them.
+// Each name can be used once per test, names can't be numbers as they would collide with numbered snapshots.
+func (c *Config) MatchNamed(t TestingT, name string, values ...any) {
+	t.Helper()
+
+	newSnap(c, t).withName(name).matchSnapshot(values...)
+}
+
+// MatchNamedJSON verifies the input matches the json snapshot stored under the given name,
+// see `MatchJSON` and `MatchNamed`
+//
+//	MatchNamedJSON(t, "order", order, match.Any("created"))
+func (c *Config) MatchNamedJSON(t TestingT, name string, input any, matchers ...matchers.JsonMatcher) {
+	t.Helper()
+
+	newSnap(c, t).withName(name).matchJson(input, matchers...)
+}
+
+// MatchNamedStandalone verifies the value matches the snapshot stored under the given name,
+// see `MatchStandaloneSnapshot` and `MatchNamed`
+func (c *Config) MatchNamedStandalone(t TestingT, name string, value any) {
+	t.Helper()
+
+	newSnap(c, t).withName(name).matchStandaloneSnapshot(value)
+}
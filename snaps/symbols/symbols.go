@@ -5,4 +5,7 @@ const (
 	UpdateSymbol  = "✎ "
 	NewLineSymbol = "↵"
 	SkipSymbol    = "⟳ "
+	SuccessSymbol = "✓ "
+	InfoSymbol    = "ℹ "
+	EnterSymbol   = "↳ "
 )
@@ -0,0 +1,194 @@
+package snaps
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var templateToken = regexp.MustCompile(`\{(package|file|test|subtest|n|name)\}`)
+
+// normalizeTemplate makes sure snapshots taken in the same test get different paths
+func normalizeTemplate(tmpl string) string {
+	if !strings.Contains(tmpl, "{n}") && !strings.Contains(tmpl, "{name}") {
+		return tmpl + "_{n}"
+	}
+
+	return tmpl
+}
+
+// expandTemplate returns the snapshot filename, without extension, from the `FilenameTemplate`.
+// For numbered snapshots the counter is left as `%d`, see numbered.
+func (s *snap) expandTemplate(callerFilename string) string {
+	escape := func(v string) string { return v }
+	if s.numbered() {
+		escape = escapePercent
+	}
+
+	base := filepath.Base(callerFilename)
+	test, subtest, _ := strings.Cut(s.t.Name(), "/")
+	id := "%d"
+	if s.named {
+		id = testFilename(s.name)
+	}
+
+	return templateToken.ReplaceAllStringFunc(escape(normalizeTemplate(s.c.FilenameTemplate())), func(token string) string {
+		switch token {
+		case "{package}":
+			return escape(sanitizeFilename(filepath.Base(filepath.Dir(callerFilename))))
+		case "{file}":
+			return escape(sanitizeFilename(strings.TrimSuffix(base, filepath.Ext(base))))
+		case "{test}":
+			return escape(sanitizeFilename(test))
+		case "{subtest}":
+			return escape(sanitizeSubtest(subtest))
+		default:
+			return id
+		}
+	})
+}
+
+// sanitizeSubtest sanitizes every level of nested subtests as they are stored in nested dirs
+func sanitizeSubtest(subtest string) string {
+	if subtest == "" {
+		return ""
+	}
+
+	levels := strings.Split(subtest, "/")
+	for i, level := range levels {
+		levels[i] = sanitizeFilename(level)
+	}
+
+	return strings.Join(levels, "/")
+}
+
+// registerTemplate records the snapshot dir and template in use, so Clean can tell which
+// snapshot files belong to skipped tests
+func (s *snap) registerTemplate(dir string) {
+	s.registry.registryMutex.Lock()
+	defer s.registry.registryMutex.Unlock()
+
+	if s.registry.registryTemplates[dir] == nil {
+		s.registry.registryTemplates[dir] = make(map[string]int)
+	}
+	s.registry.registryTemplates[dir][s.c.FilenameTemplate()]++
+	if s.registry.registryStores[dir] == nil {
+		s.registry.registryStores[dir] = s.c.Store()
+	}
+}
+
+// snapshotDirs returns the snapshot dirs touched during the run along with every dir nested in the
+// dirs templates were used in, so the snapshots of renamed or removed subtests are found too.
+// Nested dirs use the snapshot extensions and store of their template dir, callers must hold registryMutex.
+func (r *snapRegistry) snapshotDirs() (map[string]map[string]int, error) {
+	dirs := make(map[string]map[string]int, len(r.registryDirs))
+	for dir, extensions := range r.registryDirs {
+		dirs[dir] = extensions
+	}
+
+	for root := range r.registryTemplates {
+		extensions := make(map[string]int)
+		for dir, used := range r.registryDirs {
+			if rel, err := filepath.Rel(root, dir); err == nil && !strings.HasPrefix(rel, "..") {
+				for ext, n := range used {
+					extensions[ext] += n
+				}
+			}
+		}
+
+		store := r.store(root)
+		pending := []string{root}
+		for len(pending) > 0 {
+			dir := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+
+			names, err := listDirs(store, dir)
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				return nil, err
+			}
+			for _, name := range names {
+				pending = append(pending, filepath.Join(dir, name))
+			}
+
+			if dirs[dir] == nil {
+				dirs[dir] = make(map[string]int)
+			}
+			for ext, n := range extensions {
+				dirs[dir][ext] += n
+			}
+			if r.registryStores[dir] == nil {
+				r.registryStores[dir] = store
+			}
+		}
+	}
+
+	return dirs, nil
+}
+
+// templatePattern matches the paths, relative to the snapshot dir, the template produces for the test
+// and its subtests regardless of file, counter and name
+func templatePattern(tmpl, name string) *regexp.Regexp {
+	test, subtest, hasSubtest := strings.Cut(name, "/")
+	tmpl = normalizeTemplate(tmpl)
+
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	for _, loc := range templateToken.FindAllStringIndex(tmpl, -1) {
+		pattern.WriteString(regexp.QuoteMeta(tmpl[last:loc[0]]))
+		switch tmpl[loc[0]:loc[1]] {
+		case "{package}", "{file}":
+			pattern.WriteString(`[^/]*`)
+		case "{test}":
+			pattern.WriteString(regexp.QuoteMeta(sanitizeFilename(test)))
+		case "{subtest}":
+			if hasSubtest {
+				pattern.WriteString(regexp.QuoteMeta(sanitizeSubtest(subtest)) + `(/.*)?`)
+			} else {
+				pattern.WriteString(`.*`)
+			}
+		default:
+			pattern.WriteString(`.+`)
+		}
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(tmpl[last:]) + `\.[^/]+$`)
+
+	return regexp.MustCompile(pattern.String())
+}
+
+// producedBySkippedTests reports whether one of the templates used in a parent dir of path
+// could have produced the snapshot for one of the skipped tests, callers must hold registryMutex
+func (r *snapRegistry) producedBySkippedTests(path string, skipped []string) bool {
+	for dir, templates := range r.registryTemplates {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		for tmpl := range templates {
+			for _, name := range skipped {
+				if templatePattern(tmpl, name).MatchString(filepath.ToSlash(rel)) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// inTemplateDir reports whether dir is nested in a dir templates were used in, callers must hold registryMutex
+func (r *snapRegistry) inTemplateDir(dir string) bool {
+	for root := range r.registryTemplates {
+		if rel, err := filepath.Rel(root, dir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+			return true
+		}
+	}
+
+	return false
+}
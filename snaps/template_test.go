@@ -0,0 +1,123 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilenameTemplate(t *testing.T) {
+	resetEnv(t)
+
+	setup := func(t *testing.T, name string) (test.MockTestingT, *[]any) {
+		mockT, errs := test.NewRecordingMockTestingT(t, name)
+		mockT.MockCleanup = t.Cleanup
+
+		return mockT, errs
+	}
+
+	t.Run("should store snapshots in nested dirs", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir), FilenameTemplate("{file}/{test}/{subtest}_{n}"))
+		mockT, errs := setup(t, "TestFoo/case/nested")
+
+		c.MatchSnapshot(mockT, "first")
+		c.MatchJSON(mockT, `{"second":true}`)
+		c.MatchSnapshot(mockT, "third")
+
+		test.Equal(t, 0, len(*errs))
+		base := filepath.Join(dir, "template_test", "TestFoo", "case", "nested")
+		test.Equal(t, "first", test.GetFileContent(t, base+"_1.snap"))
+		test.Equal(t, "{\n \"second\": true\n}", test.GetFileContent(t, base+"_1.json"))
+		test.Equal(t, "third", test.GetFileContent(t, base+"_2.snap"))
+	})
+
+	t.Run("should append the counter when missing", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir), FilenameTemplate("{package}-{test}"))
+		mockT, errs := setup(t, "TestFoo")
+
+		c.MatchSnapshot(mockT, "first")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "first", test.GetFileContent(t, filepath.Join(dir, "snaps-TestFoo_1.snap")))
+	})
+
+	t.Run("should use the name of named snapshots", func(t *testing.T) {
+		dir := t.TempDir()
+		c := WithConfig(Dir(dir), FilenameTemplate("{test}/{name}"))
+		mockT, errs := setup(t, "TestFoo")
+
+		c.MatchNamed(mockT, "total", 10)
+		c.MatchSnapshot(mockT, "numbered")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "int(10)", test.GetFileContent(t, filepath.Join(dir, "TestFoo", "total.snap")))
+		test.Equal(t, "numbered", test.GetFileContent(t, filepath.Join(dir, "TestFoo", "1.snap")))
+	})
+
+	t.Run("should escape percent signs of test names", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "100%d")
+		mockT, errs := setup(t, "TestFoo/100%")
+
+		WithConfig(Dir(dir)).MatchSnapshot(mockT, "default")
+		WithConfig(Dir(dir), FilenameTemplate("{subtest}-{test}-{n}")).MatchSnapshot(mockT, "template")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "default", test.GetFileContent(t, filepath.Join(dir, "TestFoo_100%25_1.snap")))
+		test.Equal(t, "template", test.GetFileContent(t, filepath.Join(dir, "100%25-TestFoo-1.snap")))
+	})
+}
+
+func TestTemplatePattern(t *testing.T) {
+	tmpl := "{file}/{test}/{subtest}_{n}"
+
+	test.True(t, templatePattern(tmpl, "TestFoo").MatchString("handler_test/TestFoo/case_1.snap"))
+	test.True(t, templatePattern(tmpl, "TestFoo").MatchString("handler_test/TestFoo/_1.snap"))
+	test.True(t, templatePattern(tmpl, "TestFoo/case").MatchString("handler_test/TestFoo/case/nested_2.json"))
+	test.False(t, templatePattern(tmpl, "TestFoo/case").MatchString("handler_test/TestFoo/other_1.snap"))
+	test.False(t, templatePattern(tmpl, "TestFoo").MatchString("handler_test/TestFooBar/case_1.snap"))
+	test.True(t, templatePattern("{test}", "TestFoo").MatchString("TestFoo_1.snap"))
+}
+
+func TestObsoleteTemplateFiles(t *testing.T) {
+	t.Run("should ignore snapshots of skipped tests", func(t *testing.T) {
+		dir := setupSnapsDir(t, "TestA/case_1.snap", "TestA/skipped_1.snap", "TestA/obsolete_1.snap")
+		r := newSnapRegistry()
+		r.registryDirs[filepath.Join(dir, "TestA")] = map[string]int{".snap": 1}
+		r.registryTemplates[dir] = map[string]int{"{test}/{subtest}_{n}": 1}
+		r.registryCleanup[filepath.Join(dir, "TestA", "case_1.snap")] = 1
+		r.skippedTests = append(r.skippedTests, "TestA/skipped")
+
+		obsolete, err := r.obsoleteFiles(false)
+
+		test.NoError(t, err)
+		test.Equal(t, []string{filepath.Join(dir, "TestA", "obsolete_1.snap")}, obsolete)
+	})
+	t.Run("should report and delete snapshots of removed subtests", func(t *testing.T) {
+		dir := setupSnapsDir(t, "TestA/case_1.snap", "TestA/removed/nested_1.snap", "TestRemoved/case_1.snap")
+		r := newSnapRegistry()
+		r.registryDirs[filepath.Join(dir, "TestA")] = map[string]int{".snap": 1}
+		r.registryTemplates[dir] = map[string]int{"{test}/{subtest}_{n}": 1}
+		r.registryCleanup[filepath.Join(dir, "TestA", "case_1.snap")] = 1
+
+		obsolete, err := r.obsoleteFiles(false)
+
+		test.NoError(t, err)
+		test.Equal(t, []string{
+			filepath.Join(dir, "TestA", "removed", "nested_1.snap"),
+			filepath.Join(dir, "TestRemoved", "case_1.snap"),
+		}, obsolete)
+
+		_, err = r.deleteFiles(obsolete)
+		test.NoError(t, err)
+		for _, path := range []string{"TestA/removed", "TestRemoved"} {
+			_, err := os.Stat(filepath.Join(dir, path))
+			test.True(t, errors.Is(err, fs.ErrNotExist))
+		}
+		test.Equal(t, "mock-snapshot", test.GetFileContent(t, filepath.Join(dir, "TestA", "case_1.snap")))
+	})
+}
@@ -0,0 +1,128 @@
+package snaps
+
+import (
+	"regexp"
+	"strings"
+)
+
+// updateMismatches is the mode of UPDATE_SNAPS=true and `Update(true)`, writing missing and mismatching snapshots
+const updateMismatches UpdateMode = "true"
+
+// updateKeywords are UPDATE_SNAPS values with a special meaning, anything else is a list of test patterns
+var updateKeywords = map[string]bool{
+	"":                       true,
+	"false":                  true,
+	"clean":                  true,
+	string(UpdateNone):       true,
+	string(UpdateNew):        true,
+	string(UpdateFailing):    true,
+	string(UpdateAll):        true,
+	string(UpdatePending):    true,
+	string(updateMismatches): true,
+}
+
+// updateMode resolves the mode for the current test, in order of precedence from
+// the CI policy, `Mode`, `Update`/`UpdateOnly` and UPDATE_SNAPS
+func (s *snap) updateMode() UpdateMode {
+	if s.c.IsCI() {
+		return s.c.CI().mode()
+	}
+
+	if m := s.c.Mode(); m != "" {
+		return m
+	}
+	if patterns := s.c.UpdateOnly(); len(patterns) > 0 && !matchesTest(compileTestPatterns(patterns), s.t.Name()) {
+		return UpdateNew
+	}
+	if u := s.c.Update(); u != nil {
+		if *u {
+			return updateMismatches
+		}
+		return UpdateNew
+	}
+
+	if patterns := parseUpdatePatterns(updateVAR); len(patterns) > 0 {
+		if matchesTest(patterns, s.t.Name()) {
+			return updateMismatches
+		}
+		return UpdateNew
+	}
+	switch m := UpdateMode(updateVAR); m {
+	case UpdateNone, UpdateNew, UpdateFailing, UpdateAll, UpdatePending, updateMismatches:
+		return m
+	}
+
+	return UpdateNew
+}
+
+func (m UpdateMode) writesNew() bool {
+	return m == UpdateNew || m == UpdateAll || m == updateMismatches
+}
+
+func (m UpdateMode) writesFailing() bool {
+	return m == UpdateFailing || m == UpdateAll || m == updateMismatches
+}
+
+func (m UpdateMode) writesPassing() bool {
+	return m == UpdateAll
+}
+
+func (m UpdateMode) String() string {
+	if m == updateMismatches {
+		return "new+failing"
+	}
+
+	return string(m)
+}
+
+func (s *snap) registerUpdateMode(m UpdateMode) {
+	s.registry.registryMutex.Lock()
+	defer s.registry.registryMutex.Unlock()
+	s.registry.registryModes[m]++
+}
+
+// parseUpdatePatterns splits a comma separated list of test name patterns, returns nil for UPDATE_SNAPS keywords
+func parseUpdatePatterns(value string) []*regexp.Regexp {
+	if updateKeywords[value] {
+		return nil
+	}
+
+	return compileTestPatterns(strings.Split(value, ","))
+}
+
+// compileTestPatterns compiles patterns anchored to the whole test name,
+// patterns that are not valid regular expressions are matched literally
+func compileTestPatterns(patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + p + ")$")
+		if err != nil {
+			re = regexp.MustCompile("^" + regexp.QuoteMeta(p) + "$")
+		}
+		compiled = append(compiled, re)
+	}
+
+	return compiled
+}
+
+// matchesTest reports whether the test or one of its parents matches any of the patterns
+// e.g. `TestCheckout` matches `TestCheckout/success` while `TestCheckout/.*` matches only subtests.
+func matchesTest(patterns []*regexp.Regexp, name string) bool {
+	for {
+		for _, re := range patterns {
+			if re.MatchString(name) {
+				return true
+			}
+		}
+
+		i := strings.LastIndexByte(name, '/')
+		if i == -1 {
+			return false
+		}
+		name = name[:i]
+	}
+}
@@ -0,0 +1,155 @@
+package snaps
+
+import (
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpdatePatterns(t *testing.T) {
+	t.Run("should ignore UPDATE_SNAPS keywords", func(t *testing.T) {
+		for _, v := range []string{"", "true", "false", "clean", "pending"} {
+			test.Equal(t, 0, len(parseUpdatePatterns(v)))
+		}
+	})
+
+	t.Run("should match tests and their subtests", func(t *testing.T) {
+		patterns := parseUpdatePatterns("TestCheckout/.*, TestCart,Test[")
+
+		test.Equal(t, 3, len(patterns))
+		test.True(t, matchesTest(patterns, "TestCheckout/success"))
+		test.True(t, matchesTest(patterns, "TestCheckout/success/nested"))
+		test.False(t, matchesTest(patterns, "TestCheckout"))
+		test.True(t, matchesTest(patterns, "TestCart"))
+		test.True(t, matchesTest(patterns, "TestCart/empty"))
+		test.False(t, matchesTest(patterns, "TestCartTotal"))
+		test.True(t, matchesTest(patterns, "Test["))
+	})
+
+	t.Run("should update only matching tests", func(t *testing.T) {
+		resetEnv(t)
+		updateVAR = "TestCheckout/.*"
+
+		mockT := test.NewMockTestingT(t)
+		name := "TestCheckout/success"
+		mockT.MockName = func() string {
+			return name
+		}
+
+		test.Equal(t, updateMismatches, newSnap(defaultConfig(), mockT).updateMode())
+		test.Equal(t, updateMismatches, newSnap(WithConfig(UpdateOnly("TestCheckout")), mockT).updateMode())
+		test.Equal(t, UpdateNew, newSnap(WithConfig(UpdateOnly("TestCart")), mockT).updateMode())
+		test.Equal(t, UpdateNew, newSnap(WithConfig(Update(false)), mockT).updateMode())
+
+		name = "TestCart"
+		test.Equal(t, UpdateNew, newSnap(defaultConfig(), mockT).updateMode())
+	})
+
+	t.Run("should apply the mode to every test regardless of UpdateOnly", func(t *testing.T) {
+		resetEnv(t)
+
+		mockT := test.NewMockTestingT(t)
+		mockT.MockName = func() string {
+			return "TestCart"
+		}
+
+		test.Equal(t, UpdateAll, newSnap(WithConfig(UpdateOnly("TestCheckout"), Mode(UpdateAll)), mockT).updateMode())
+		test.Equal(t, UpdateNone, newSnap(WithConfig(Mode(UpdateNone), UpdateOnly("TestCart")), mockT).updateMode())
+	})
+}
+
+func TestUpdateMode(t *testing.T) {
+	resetEnv(t)
+	mockT := test.NewMockTestingT(t)
+
+	t.Run("should resolve mode", func(t *testing.T) {
+		isCI = false
+		for _, v := range []struct {
+			env      string
+			config   *Config
+			expected UpdateMode
+		}{
+			{"", defaultConfig(), UpdateNew},
+			{"false", defaultConfig(), UpdateNew},
+			{"clean", defaultConfig(), UpdateNew},
+			{"true", defaultConfig(), updateMismatches},
+			{"none", defaultConfig(), UpdateNone},
+			{"new", defaultConfig(), UpdateNew},
+			{"failing", defaultConfig(), UpdateFailing},
+			{"all", defaultConfig(), UpdateAll},
+			{"pending", defaultConfig(), UpdatePending},
+			{"pending", WithConfig(Update(true)), updateMismatches},
+			{"true", WithConfig(Mode(UpdateNone)), UpdateNone},
+			{"", WithConfig(Update(true), Mode(UpdateAll)), UpdateAll},
+		} {
+			updateVAR = v.env
+			test.Equal(t, v.expected, newSnap(v.config, mockT).updateMode())
+		}
+	})
+
+	t.Run("should never write on CI", func(t *testing.T) {
+		isCI = true
+		updateVAR = "all"
+
+		test.Equal(t, UpdateNone, newSnap(WithConfig(Mode(UpdateAll)), mockT).updateMode())
+	})
+}
+
+func TestMatchWithUpdateMode(t *testing.T) {
+	resetEnv(t)
+
+	setup := func(t *testing.T, snapshot string) (string, test.MockTestingT, *[]any) {
+		dir := t.TempDir()
+		if snapshot != "" {
+			_ = os.WriteFile(filepath.Join(dir, "TestMode_1.snap.json"), []byte(snapshot), os.ModePerm)
+		}
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMode")
+
+		return filepath.Join(dir, "TestMode_1.snap.json"), mockT, errs
+	}
+
+	t.Run("none should not write missing snapshots", func(t *testing.T) {
+		path, mockT, errs := setup(t, "")
+
+		WithConfig(Dir(filepath.Dir(path)), Mode(UpdateNone)).MatchSnapshot(mockT, "hello")
+
+		test.Equal(t, []any{errSnapNotFound}, *errs)
+		_, err := os.Stat(path)
+		test.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("new should not rewrite mismatches", func(t *testing.T) {
+		path, mockT, errs := setup(t, "hello")
+
+		WithConfig(Dir(filepath.Dir(path)), Mode(UpdateNew), Ext(".json")).MatchSnapshot(mockT, "world")
+
+		test.Equal(t, 1, len(*errs))
+		test.Equal(t, "hello", test.GetFileContent(t, path))
+	})
+
+	t.Run("failing should rewrite mismatches but not missing snapshots", func(t *testing.T) {
+		path, mockT, errs := setup(t, "hello")
+
+		WithConfig(Dir(filepath.Dir(path)), Mode(UpdateFailing), Ext(".json")).MatchSnapshot(mockT, "world")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "world", test.GetFileContent(t, path))
+
+		_ = os.Remove(path)
+		WithConfig(Dir(filepath.Dir(path)), Mode(UpdateFailing), Ext(".json")).MatchSnapshot(mockT, "world")
+
+		test.Equal(t, []any{errSnapNotFound}, *errs)
+	})
+
+	t.Run("all should rewrite passing snapshots with different formatting", func(t *testing.T) {
+		path, mockT, errs := setup(t, "")
+		path = filepath.Join(filepath.Dir(path), "TestMode_1.json")
+		_ = os.WriteFile(path, []byte(`{"a":1}`), os.ModePerm)
+
+		WithConfig(Dir(filepath.Dir(path)), Mode(UpdateAll)).MatchJSON(mockT, `{"a":1}`)
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "{\n \"a\": 1\n}", test.GetFileContent(t, path))
+	})
+}
@@ -0,0 +1,81 @@
+package snaps
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/internal/xmltree"
+	"github.com/KoNekoD/go-snaps/snaps/matchers"
+)
+
+var errInvalidXML = errors.New("invalid xml")
+
+func (s *snap) matchXML(input any, matchersList ...matchers.XMLMatcher) {
+	s.fileExtension = ".xml"
+	s.format = "xml"
+	s.t.Helper()
+
+	b, err := s.validateXML(input)
+	if err != nil {
+		s.handleError(err)
+		return
+	}
+
+	var matchersErrors []matchers.MatcherError
+	for _, m := range matchersList {
+		matched, errs := m.XML(b)
+		if len(errs) > 0 {
+			matchersErrors = append(matchersErrors, errs...)
+			continue
+		}
+		b = matched
+	}
+	if len(matchersErrors) > 0 {
+		s.handleError(formatMatchersErrors(matchersErrors))
+		return
+	}
+
+	doc, err := xmltree.Parse(b)
+	if err != nil {
+		s.handleError(fmt.Errorf("%w: %w", errInvalidXML, err))
+		return
+	}
+	s.handleSnapshot(doc.Encode())
+}
+
+// validateXML returns xml strings and []byte as they are, other values are converted with `xml.Marshal`
+func (s *snap) validateXML(input any) ([]byte, error) {
+	var b []byte
+	switch x := input.(type) {
+	case string:
+		b = []byte(x)
+	case []byte:
+		b = x
+	default:
+		var err error
+		if b, err = xml.Marshal(input); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := xmltree.Parse(b); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidXML, err)
+	}
+
+	return b, nil
+}
+
+// xmlEqual reports whether both snapshots are the same xml document, regardless of formatting,
+// namespace prefixes and attribute order
+func xmlEqual(a, b string) bool {
+	x, err := xmltree.Parse([]byte(a))
+	if err != nil {
+		return false
+	}
+	y, err := xmltree.Parse([]byte(b))
+	if err != nil {
+		return false
+	}
+
+	return xmltree.Equal(x, y)
+}
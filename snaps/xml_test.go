@@ -0,0 +1,105 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"github.com/KoNekoD/go-snaps/snaps/matchers"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchXML(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should write canonical xml", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchXML")
+
+		WithConfig(Dir(dir)).MatchXML(mockT, `<?xml version="1.0"?><user role="admin" id="1"><name>mock-user</name><email></email></user>`)
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(
+			t,
+			"<user id=\"1\" role=\"admin\">\n  <name>mock-user</name>\n  <email/>\n</user>",
+			test.GetFileContent(t, filepath.Join(dir, "TestMatchXML_1.xml")),
+		)
+	})
+
+	t.Run("should marshal values", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchXML")
+		value := struct {
+			XMLName struct{} `xml:"user"`
+			ID      int      `xml:"id,attr"`
+		}{ID: 1}
+
+		WithConfig(Dir(dir)).MatchXML(mockT, value)
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, `<user id="1"/>`, test.GetFileContent(t, filepath.Join(dir, "TestMatchXML_1.xml")))
+	})
+
+	t.Run("should match the same document with other prefixes and formatting", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = os.WriteFile(
+			filepath.Join(dir, "TestMatchXML_1.xml"),
+			[]byte(`<s:a xmlns:s="urn:a" y="2" x="1"><s:b>text</s:b></s:a>`),
+			os.ModePerm,
+		)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchXML")
+
+		WithConfig(Dir(dir)).MatchXML(mockT, []byte(`<a xmlns="urn:a" x="1" y="2">
+  <b>text</b>
+</a>`))
+
+		test.Equal(t, 0, len(*errs))
+	})
+
+	t.Run("should fail on different documents", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(dir, "TestMatchXML_1.xml"), []byte(`<a x="1"/>`), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchXML")
+
+		WithConfig(Dir(dir)).MatchXML(mockT, `<a x="2"/>`)
+
+		test.Equal(t, 1, len(*errs))
+	})
+
+	t.Run("should apply matchers", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchXML")
+
+		WithConfig(Dir(dir)).MatchXML(
+			mockT,
+			`<Assertion ID="_8e8dc5f69a98cc4c" IssueInstant="2024-01-01T00:00:00Z"><Issuer>idp</Issuer></Assertion>`,
+			matchers.Any("//Assertion/@ID"),
+			matchers.Type[string]("/Assertion/@IssueInstant"),
+		)
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(
+			t,
+			"<Assertion ID=\"&lt;Any value&gt;\" IssueInstant=\"&lt;Type:string&gt;\">\n  <Issuer>idp</Issuer>\n</Assertion>",
+			test.GetFileContent(t, filepath.Join(dir, "TestMatchXML_1.xml")),
+		)
+	})
+
+	t.Run("should report matcher errors", func(t *testing.T) {
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchXML")
+
+		WithConfig(Dir(t.TempDir())).MatchXML(mockT, `<a/>`, matchers.Any("/a/@id"))
+
+		test.Equal(t, 1, len(*errs))
+		test.Contains(t, (*errs)[0].(string), `match.Any("/a/@id") - path does not exist`)
+	})
+
+	t.Run("should report invalid xml", func(t *testing.T) {
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchXML")
+
+		WithConfig(Dir(t.TempDir())).MatchXML(mockT, `<a><b></a>`)
+
+		test.Equal(t, 1, len(*errs))
+		test.True(t, errors.Is((*errs)[0].(error), errInvalidXML))
+	})
+}
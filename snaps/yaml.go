@@ -0,0 +1,207 @@
+package snaps
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/KoNekoD/go-snaps/snaps/matchers"
+	"gopkg.in/yaml.v3"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var errInvalidYAML = errors.New("invalid yaml")
+
+func (s *snap) matchYAML(input any, matchersList ...matchers.JsonMatcher) {
+	s.fileExtension = ".yaml"
+	s.format = "yaml"
+	s.t.Helper()
+
+	docs, err := s.validateYAML(input)
+	if err != nil {
+		s.handleError(err)
+		return
+	}
+
+	if len(matchersList) > 0 {
+		var matchersErrors []matchers.MatcherError
+		for i, doc := range docs {
+			b, err := yamlToJSON(doc)
+			if err != nil {
+				s.handleError(err)
+				return
+			}
+			b, errs := s.applyJsonMatchers(b, matchersList...)
+			if len(errs) > 0 {
+				matchersErrors = append(matchersErrors, errs...)
+				continue
+			}
+			matched, err := parseYAML(b)
+			if err != nil {
+				s.handleError(err)
+				return
+			}
+			docs[i] = matched[0]
+		}
+		if len(matchersErrors) > 0 {
+			s.handleError(formatMatchersErrors(matchersErrors))
+			return
+		}
+	}
+
+	snapshot, err := s.snapshotSerializer.takeYAMLSnapshot(docs)
+	if err != nil {
+		s.handleError(err)
+		return
+	}
+	s.handleSnapshot(snapshot)
+}
+
+// validateYAML returns the documents of yaml strings and []byte, other values are converted
+// through `json.Marshal` so json tags are respected as with Kubernetes objects
+func (s *snap) validateYAML(input any) ([]*yaml.Node, error) {
+	switch y := input.(type) {
+	case string:
+		return parseYAML([]byte(y))
+	case []byte:
+		return parseYAML(y)
+	default:
+		b, err := json.Marshal(input)
+		if err != nil {
+			return nil, err
+		}
+		return parseYAML(b)
+	}
+}
+
+// parseYAML returns every document of a yaml stream e.g. Kubernetes manifests separated by `---`
+func parseYAML(b []byte) ([]*yaml.Node, error) {
+	docs := make([]*yaml.Node, 0, 1)
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidYAML, err)
+		}
+		docs = append(docs, &doc)
+	}
+}
+
+// yamlToJSON converts a yaml document to json keeping the order of keys, so matchers can be applied
+func yamlToJSON(node *yaml.Node) ([]byte, error) {
+	var b bytes.Buffer
+	if err := writeYAMLAsJSON(&b, node); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func writeYAMLAsJSON(b *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			b.WriteString("null")
+			return nil
+		}
+		return writeYAMLAsJSON(b, node.Content[0])
+	case yaml.AliasNode:
+		return writeYAMLAsJSON(b, node.Alias)
+	case yaml.MappingNode:
+		b.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			b.Write(key)
+			b.WriteByte(':')
+			if err := writeYAMLAsJSON(b, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case yaml.SequenceNode:
+		b.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeYAMLAsJSON(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	default:
+		var v any
+		if err := node.Decode(&v); err != nil {
+			return err
+		}
+		scalar, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(scalar)
+	}
+
+	return nil
+}
+
+// normalizeYAML drops comments and styles so documents are written in block style regardless of the
+// input, optionally sorting keys
+func normalizeYAML(node *yaml.Node, sortKeys bool) {
+	node.Style = 0
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+	for _, child := range node.Content {
+		normalizeYAML(child, sortKeys)
+	}
+
+	if node.Kind != yaml.MappingNode || !sortKeys {
+		return
+	}
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i][0].Value < pairs[j][0].Value })
+	for i, pair := range pairs {
+		node.Content[2*i], node.Content[2*i+1] = pair[0], pair[1]
+	}
+}
+
+// yamlEqual reports whether both snapshots contain the same yaml documents, regardless of formatting
+func yamlEqual(a, b string) bool {
+	x, err := decodeYAML(a)
+	if err != nil {
+		return false
+	}
+	y, err := decodeYAML(b)
+	if err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(x, y)
+}
+
+func decodeYAML(s string) ([]any, error) {
+	docs := make([]any, 0, 1)
+	decoder := yaml.NewDecoder(strings.NewReader(s))
+	for {
+		var doc any
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+}
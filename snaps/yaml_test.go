@@ -0,0 +1,113 @@
+package snaps
+
+import (
+	"errors"
+	"github.com/KoNekoD/go-snaps/internal/test"
+	"github.com/KoNekoD/go-snaps/snaps/matchers"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchYAML(t *testing.T) {
+	resetEnv(t)
+
+	t.Run("should write yaml in block style without comments", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchYAML")
+
+		WithConfig(Dir(dir)).MatchYAML(mockT, "# user\nname: mock-user\nroles: [admin, dev]\nage: 10\n")
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(
+			t,
+			"name: mock-user\nroles:\n  - admin\n  - dev\nage: 10",
+			test.GetFileContent(t, filepath.Join(dir, "TestMatchYAML_1.yaml")),
+		)
+	})
+
+	t.Run("should sort keys and convert values through json", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchYAML")
+		value := struct {
+			Name string `json:"name"`
+			Age  int    `json:"age"`
+		}{"mock-user", 10}
+
+		WithConfig(Dir(dir), SortProperties()).MatchYAML(mockT, value)
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(t, "age: 10\nname: mock-user", test.GetFileContent(t, filepath.Join(dir, "TestMatchYAML_1.yaml")))
+	})
+
+	t.Run("should keep every document", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchYAML")
+
+		WithConfig(Dir(dir)).MatchYAML(mockT, []byte("kind: Service\n---\nkind: Deployment\n"))
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(
+			t,
+			"kind: Service\n---\nkind: Deployment",
+			test.GetFileContent(t, filepath.Join(dir, "TestMatchYAML_1.yaml")),
+		)
+	})
+
+	t.Run("should match snapshots with the same data", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(dir, "TestMatchYAML_1.yaml"), []byte("b: 2\na: 1"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchYAML")
+
+		WithConfig(Dir(dir)).MatchYAML(mockT, "{a: 1, b: 2}")
+
+		test.Equal(t, 0, len(*errs))
+	})
+
+	t.Run("should fail on different data", func(t *testing.T) {
+		dir := t.TempDir()
+		_ = os.WriteFile(filepath.Join(dir, "TestMatchYAML_1.yaml"), []byte("a: 1"), os.ModePerm)
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchYAML")
+
+		WithConfig(Dir(dir)).MatchYAML(mockT, "a: '1'")
+
+		test.Equal(t, 1, len(*errs))
+	})
+
+	t.Run("should apply json matchers", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchYAML")
+
+		WithConfig(Dir(dir)).MatchYAML(
+			mockT,
+			"metadata:\n  name: web\n  uid: 1f2e\nspec:\n  replicas: 3\n",
+			matchers.Any("metadata.uid"),
+		)
+
+		test.Equal(t, 0, len(*errs))
+		test.Equal(
+			t,
+			"metadata:\n  name: web\n  uid: <Any value>\nspec:\n  replicas: 3",
+			test.GetFileContent(t, filepath.Join(dir, "TestMatchYAML_1.yaml")),
+		)
+	})
+
+	t.Run("should report matcher errors", func(t *testing.T) {
+		dir := t.TempDir()
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchYAML")
+
+		WithConfig(Dir(dir)).MatchYAML(mockT, "name: web\n", matchers.Any("uid"))
+
+		test.Equal(t, 1, len(*errs))
+		test.Contains(t, (*errs)[0].(string), `match.Any("uid") - path does not exist`)
+	})
+
+	t.Run("should report invalid yaml", func(t *testing.T) {
+		mockT, errs := test.NewRecordingMockTestingT(t, "TestMatchYAML")
+
+		WithConfig(Dir(t.TempDir())).MatchYAML(mockT, "a: [1, 2")
+
+		test.Equal(t, 1, len(*errs))
+		test.True(t, errors.Is((*errs)[0].(error), errInvalidYAML))
+	})
+}